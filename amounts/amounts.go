@@ -0,0 +1,128 @@
+// amounts.go
+package amounts
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// Units of an amount, the consensus fields are in Hanas
+const (
+	UnitTBYT  = "TBYT"
+	UnitHanas = "Hanas"
+)
+
+// TBYTDecimals is the number of decimals of a TBYT. The ratio is the one the
+// wallet has documented for AMOUNT since its first release, "1 TBT =
+// 10,000,000 Hanabytes"; the chain itself only ever sees Hanas.
+const TBYTDecimals = 7
+
+// HanasPerTBYT is the number of Hanas in one TBYT, 10^TBYTDecimals
+var HanasPerTBYT = new(big.Int).Exp(big.NewInt(10), big.NewInt(TBYTDecimals), nil)
+
+var (
+	ErrInvalidAmount   = errors.New("invalid amount")
+	ErrExcessPrecision = errors.New("amount has more decimals than its unit")
+	ErrUnknownUnit     = errors.New("unknown unit")
+	ErrAmountRange     = errors.New("amount out of range")
+)
+
+// ParseUnit reads a unit name, case insensitive
+func ParseUnit(input string) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(input)) {
+	case "tbyt":
+		return UnitTBYT, nil
+	case "hanas", "hana":
+		return UnitHanas, nil
+	}
+	return "", fmt.Errorf("%w %q, use TBYT or hanas", ErrUnknownUnit, input)
+}
+
+// decimals returns the number of decimals an amount in unit can have
+func decimals(unit string) int {
+	if unit == UnitTBYT {
+		return TBYTDecimals
+	}
+	return 0
+}
+
+// Parse reads an amount such as 1.5TBYT, 1500000hanas or 0.001 and returns it
+// in Hanas. An amount without a unit is in defaultUnit. Decimals the unit
+// can't hold are an error, they are never rounded away.
+func Parse(input string, defaultUnit string) (*big.Int, error) {
+	trimmed := strings.TrimSpace(input)
+	number := strings.TrimRightFunc(trimmed, func(c rune) bool {
+		return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+	})
+	unit := defaultUnit
+	if suffix := trimmed[len(number):]; suffix != "" {
+		var err error
+		unit, err = ParseUnit(suffix)
+		if err != nil {
+			return nil, err
+		}
+	}
+	number = strings.TrimSpace(number)
+
+	whole, fraction, hasPoint := strings.Cut(number, ".")
+	if !isDigits(whole) || (hasPoint && !isDigits(fraction)) {
+		return nil, fmt.Errorf("%w %q, use digits with an optional decimal point and unit, e.g. 1.5TBYT or 1500000hanas", ErrInvalidAmount, input)
+	}
+	fraction = strings.TrimRight(fraction, "0")
+	if len(fraction) > decimals(unit) {
+		return nil, fmt.Errorf("%w: %s has %d, %s has %d", ErrExcessPrecision, input, len(fraction), unit, decimals(unit))
+	}
+	digits := whole + fraction + strings.Repeat("0", decimals(unit)-len(fraction))
+	hanas, _ := new(big.Int).SetString(digits, 10)
+	return hanas, nil
+}
+
+func isDigits(value string) bool {
+	if value == "" {
+		return false
+	}
+	for _, c := range value {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// FormatValue formats an amount of Hanas in unit without the unit name. TBYT
+// has no trailing zeros.
+func FormatValue(hanas *big.Int, unit string) string {
+	if unit != UnitTBYT {
+		return hanas.String()
+	}
+	whole, fraction := new(big.Int).QuoRem(hanas, HanasPerTBYT, new(big.Int))
+	if fraction.Sign() == 0 {
+		return whole.String()
+	}
+	fractionDigits := fmt.Sprintf("%0*d", TBYTDecimals, new(big.Int).Abs(fraction))
+	sign := ""
+	if hanas.Sign() < 0 && whole.Sign() == 0 {
+		sign = "-"
+	}
+	return sign + whole.String() + "." + strings.TrimRight(fractionDigits, "0")
+}
+
+// Format formats an amount of Hanas in unit, e.g. "1.5 TBYT" or "15000000 Hanas"
+func Format(hanas *big.Int, unit string) string {
+	return FormatValue(hanas, unit) + " " + unit
+}
+
+// FormatUint formats an amount of Hanas held in a transaction field
+func FormatUint(hanas uint64, unit string) string {
+	return Format(new(big.Int).SetUint64(hanas), unit)
+}
+
+// Uint64 returns an amount of Hanas as the width of the transaction fields
+func Uint64(hanas *big.Int) (uint64, error) {
+	if hanas.Sign() < 0 || !hanas.IsUint64() {
+		return 0, fmt.Errorf("%w: the largest amount is %s", ErrAmountRange, Format(new(big.Int).SetUint64(^uint64(0)), UnitTBYT))
+	}
+	return hanas.Uint64(), nil
+}
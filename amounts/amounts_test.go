@@ -0,0 +1,164 @@
+// amounts_test.go
+package amounts
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		input       string
+		defaultUnit string
+		want        string
+	}{
+		{"1.5TBYT", UnitHanas, "15000000"},
+		{"1.5tbyt", UnitHanas, "15000000"},
+		{"1.5 TbYt", UnitHanas, "15000000"},
+		{"1500000hanas", UnitTBYT, "1500000"},
+		{"1500000HANAS", UnitTBYT, "1500000"},
+		{"1hana", UnitTBYT, "1"},
+		{"0.001", UnitTBYT, "10000"},
+		{"100", UnitHanas, "100"},
+		{" 100 ", UnitHanas, "100"},
+		{"0", UnitHanas, "0"},
+		{"0.0000001TBYT", UnitHanas, "1"},
+		{"0.10000000TBYT", UnitHanas, "1000000"},
+		{"1.000hanas", UnitTBYT, "1"},
+		{"007TBYT", UnitHanas, "70000000"},
+		{"1844674407370.9551615TBYT", UnitHanas, "18446744073709551615"},
+		{"18446744073709551616", UnitHanas, "18446744073709551616"},
+	}
+	for _, test := range tests {
+		got, err := Parse(test.input, test.defaultUnit)
+		if err != nil {
+			t.Errorf("Parse(%q, %s): %v", test.input, test.defaultUnit, err)
+			continue
+		}
+		if got.String() != test.want {
+			t.Errorf("Parse(%q, %s) = %s, want %s", test.input, test.defaultUnit, got, test.want)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		input string
+		want  error
+	}{
+		{"0.00000001TBYT", ErrExcessPrecision},
+		{"0.00000001", ErrExcessPrecision},
+		{"1.5hanas", ErrExcessPrecision},
+		{"", ErrInvalidAmount},
+		{"   ", ErrInvalidAmount},
+		{".5", ErrInvalidAmount},
+		{".5TBYT", ErrInvalidAmount},
+		{"1.", ErrInvalidAmount},
+		{"-1", ErrInvalidAmount},
+		{"-1TBYT", ErrInvalidAmount},
+		{"+1", ErrInvalidAmount},
+		{"1e5", ErrInvalidAmount},
+		{"1,000", ErrInvalidAmount},
+		{"1.2.3", ErrInvalidAmount},
+		{"TBYT", ErrInvalidAmount},
+		{"5btc", ErrUnknownUnit},
+		{"5tbyts", ErrUnknownUnit},
+	}
+	for _, test := range tests {
+		got, err := Parse(test.input, UnitTBYT)
+		if !errors.Is(err, test.want) {
+			t.Errorf("Parse(%q) = %v, %v, want %v", test.input, got, err, test.want)
+		}
+	}
+}
+
+func TestParseUnit(t *testing.T) {
+	tests := map[string]string{
+		"tbyt":  UnitTBYT,
+		"TBYT":  UnitTBYT,
+		"hanas": UnitHanas,
+		"Hanas": UnitHanas,
+		"hana":  UnitHanas,
+	}
+	for input, want := range tests {
+		got, err := ParseUnit(input)
+		if err != nil || got != want {
+			t.Errorf("ParseUnit(%q) = %q, %v, want %q", input, got, err, want)
+		}
+	}
+	if _, err := ParseUnit("btc"); !errors.Is(err, ErrUnknownUnit) {
+		t.Errorf("ParseUnit(btc) error = %v, want ErrUnknownUnit", err)
+	}
+}
+
+func TestUint64(t *testing.T) {
+	max, _ := new(big.Int).SetString("18446744073709551615", 10)
+	got, err := Uint64(max)
+	if err != nil || got != ^uint64(0) {
+		t.Errorf("Uint64(max) = %d, %v", got, err)
+	}
+	overflow := new(big.Int).Add(max, big.NewInt(1))
+	if _, err := Uint64(overflow); !errors.Is(err, ErrAmountRange) {
+		t.Errorf("Uint64(max+1) error = %v, want ErrAmountRange", err)
+	}
+	if _, err := Uint64(big.NewInt(-1)); !errors.Is(err, ErrAmountRange) {
+		t.Errorf("Uint64(-1) error = %v, want ErrAmountRange", err)
+	}
+
+	// An amount past the transaction field parses but doesn't fit
+	hanas, err := Parse("1844674407370.9551616TBYT", UnitHanas)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Uint64(hanas); !errors.Is(err, ErrAmountRange) {
+		t.Errorf("Uint64(%s) error = %v, want ErrAmountRange", hanas, err)
+	}
+}
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		hanas   int64
+		inTBYT  string
+		inHanas string
+	}{
+		{0, "0 TBYT", "0 Hanas"},
+		{1, "0.0000001 TBYT", "1 Hanas"},
+		{10000, "0.001 TBYT", "10000 Hanas"},
+		{15000000, "1.5 TBYT", "15000000 Hanas"},
+		{20000000, "2 TBYT", "20000000 Hanas"},
+		{123456789, "12.3456789 TBYT", "123456789 Hanas"},
+		{-5, "-0.0000005 TBYT", "-5 Hanas"},
+		{-15000001, "-1.5000001 TBYT", "-15000001 Hanas"},
+	}
+	for _, test := range tests {
+		if got := Format(big.NewInt(test.hanas), UnitTBYT); got != test.inTBYT {
+			t.Errorf("Format(%d, TBYT) = %q, want %q", test.hanas, got, test.inTBYT)
+		}
+		if got := Format(big.NewInt(test.hanas), UnitHanas); got != test.inHanas {
+			t.Errorf("Format(%d, Hanas) = %q, want %q", test.hanas, got, test.inHanas)
+		}
+	}
+	if got := FormatUint(^uint64(0), UnitTBYT); got != "1844674407370.9551615 TBYT" {
+		t.Errorf("FormatUint(max, TBYT) = %q", got)
+	}
+}
+
+// Every formatted amount parses back to itself in both units
+func TestFormatRoundTrip(t *testing.T) {
+	values := []string{"0", "1", "9", "10", "9999999", "10000000", "10000001", "15000000", "123456789", "18446744073709551615", "100000000000000000000000"}
+	for _, value := range values {
+		hanas, _ := new(big.Int).SetString(value, 10)
+		for _, unit := range []string{UnitTBYT, UnitHanas} {
+			formatted := Format(hanas, unit)
+			got, err := Parse(formatted, UnitHanas)
+			if err != nil {
+				t.Errorf("Parse(Format(%s, %s) = %q): %v", value, unit, formatted, err)
+				continue
+			}
+			if got.Cmp(hanas) != 0 {
+				t.Errorf("Parse(Format(%s, %s) = %q) = %s", value, unit, formatted, got)
+			}
+		}
+	}
+}
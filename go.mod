@@ -9,6 +9,7 @@ require (
 	github.com/tyler-smith/go-bip39 v1.1.0
 	golang.org/x/crypto v0.31.0
 	golang.org/x/term v0.28.0
+	golang.org/x/text v0.21.0
 )
 
 require (
@@ -0,0 +1,135 @@
+// bech32.go
+package keys
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Bech32m (BIP-350) character set and checksum constant
+const (
+	bech32Charset    = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+	bech32mConstant  = 0x2bc830a3
+	bech32MaxLength  = 90
+	bech32ChecksumLn = 6
+)
+
+// ErrBech32Checksum is returned when a Bech32m string has a wrong checksum
+var ErrBech32Checksum = errors.New("bech32m checksum is invalid")
+
+func bech32Polymod(values []byte) uint32 {
+	generator := [5]uint32{0x3b6a57b2, 0x26508e6d, 0x1ea119fa, 0x3d4233dd, 0x2a1462b3}
+	checksum := uint32(1)
+	for _, value := range values {
+		top := checksum >> 25
+		checksum = (checksum&0x1ffffff)<<5 ^ uint32(value)
+		for i := 0; i < 5; i++ {
+			if (top>>i)&1 == 1 {
+				checksum ^= generator[i]
+			}
+		}
+	}
+	return checksum
+}
+
+func bech32HRPExpand(hrp string) []byte {
+	expanded := make([]byte, 0, len(hrp)*2+1)
+	for i := 0; i < len(hrp); i++ {
+		expanded = append(expanded, hrp[i]>>5)
+	}
+	expanded = append(expanded, 0)
+	for i := 0; i < len(hrp); i++ {
+		expanded = append(expanded, hrp[i]&31)
+	}
+	return expanded
+}
+
+// convertBits regroups data from fromBits to toBits wide values
+func convertBits(data []byte, fromBits uint, toBits uint, pad bool) ([]byte, error) {
+	var accumulator uint32
+	var bits uint
+	maxValue := uint32(1)<<toBits - 1
+	var converted []byte
+	for _, value := range data {
+		if uint32(value)>>fromBits != 0 {
+			return nil, fmt.Errorf("invalid data value %d", value)
+		}
+		accumulator = accumulator<<fromBits | uint32(value)
+		bits += fromBits
+		for bits >= toBits {
+			bits -= toBits
+			converted = append(converted, byte(accumulator>>bits&maxValue))
+		}
+	}
+	if pad {
+		if bits > 0 {
+			converted = append(converted, byte(accumulator<<(toBits-bits)&maxValue))
+		}
+	} else if bits >= fromBits || accumulator<<(toBits-bits)&maxValue != 0 {
+		return nil, errors.New("invalid padding")
+	}
+	return converted, nil
+}
+
+// EncodeBech32m encodes data under the human-readable part hrp
+func EncodeBech32m(hrp string, data []byte) (string, error) {
+	values, err := convertBits(data, 8, 5, true)
+	if err != nil {
+		return "", err
+	}
+	hrp = strings.ToLower(hrp)
+	checksumInput := append(bech32HRPExpand(hrp), values...)
+	checksumInput = append(checksumInput, make([]byte, bech32ChecksumLn)...)
+	polymod := bech32Polymod(checksumInput) ^ bech32mConstant
+
+	var encoded strings.Builder
+	encoded.WriteString(hrp)
+	encoded.WriteByte('1')
+	for _, value := range values {
+		encoded.WriteByte(bech32Charset[value])
+	}
+	for i := 0; i < bech32ChecksumLn; i++ {
+		encoded.WriteByte(bech32Charset[(polymod>>(5*(5-i)))&31])
+	}
+	return encoded.String(), nil
+}
+
+// DecodeBech32m decodes a Bech32m string into its human-readable part and data
+func DecodeBech32m(encoded string) (string, []byte, error) {
+	if len(encoded) > bech32MaxLength {
+		return "", nil, errors.New("bech32m string is too long")
+	}
+	if strings.ToLower(encoded) != encoded && strings.ToUpper(encoded) != encoded {
+		return "", nil, errors.New("bech32m string mixes upper and lower case")
+	}
+	encoded = strings.ToLower(encoded)
+	separator := strings.LastIndexByte(encoded, '1')
+	if separator < 1 || separator+bech32ChecksumLn+1 > len(encoded) {
+		return "", nil, errors.New("bech32m string has no separator or checksum")
+	}
+	hrp := encoded[:separator]
+	for i := 0; i < len(hrp); i++ {
+		if hrp[i] < 33 || hrp[i] > 126 {
+			return "", nil, errors.New("bech32m prefix has an invalid character")
+		}
+	}
+
+	values := make([]byte, 0, len(encoded)-separator-1)
+	for _, c := range encoded[separator+1:] {
+		index := strings.IndexRune(bech32Charset, c)
+		if index < 0 {
+			return "", nil, fmt.Errorf("invalid bech32m character %q", c)
+		}
+		values = append(values, byte(index))
+	}
+	if bech32Polymod(append(bech32HRPExpand(hrp), values...)) != bech32mConstant {
+		return "", nil, ErrBech32Checksum
+	}
+
+	data, err := convertBits(values[:len(values)-bech32ChecksumLn], 5, 8, false)
+	if err != nil {
+		return "", nil, err
+	}
+	return hrp, data, nil
+}
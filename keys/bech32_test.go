@@ -0,0 +1,126 @@
+// bech32_test.go
+package keys
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// verifyBech32mChecksum checks only the checksum of encoded, the BIP-350
+// vectors carry data that isn't whole bytes
+func verifyBech32mChecksum(encoded string) bool {
+	encoded = strings.ToLower(encoded)
+	separator := strings.LastIndexByte(encoded, '1')
+	values := make([]byte, 0, len(encoded)-separator-1)
+	for _, c := range encoded[separator+1:] {
+		values = append(values, byte(strings.IndexRune(bech32Charset, c)))
+	}
+	return bech32Polymod(append(bech32HRPExpand(encoded[:separator]), values...)) == bech32mConstant
+}
+
+func TestBech32mValidVectors(t *testing.T) {
+	// BIP-350 valid Bech32m strings
+	vectors := []string{
+		"A1LQFN3A",
+		"a1lqfn3a",
+		"an83characterlonghumanreadablepartthatcontainsthetheexcludedcharactersbioandnumber11sg7hg6",
+		"abcdef1l7aum6echk45nj3s0wdvt2fg8x9yrzpqzd3ryx",
+		"11llllllllllllllllllllllllllllllllllllllllllllllllllllllllllllllllllllllllllllllllllludsr8",
+		"split1checkupstagehandshakeupstreamerranterredcaperredlc445v",
+		"?1v759aa",
+	}
+	for _, vector := range vectors {
+		if !verifyBech32mChecksum(vector) {
+			t.Errorf("%s: checksum is invalid", vector)
+		}
+		hrp, data, err := DecodeBech32m(vector)
+		if err != nil {
+			// Only data that isn't whole bytes may fail to decode
+			if err.Error() != "invalid padding" {
+				t.Errorf("DecodeBech32m(%s): %v", vector, err)
+			}
+			continue
+		}
+		if !strings.EqualFold(hrp, vector[:strings.LastIndexByte(vector, '1')]) {
+			t.Errorf("DecodeBech32m(%s) prefix = %q", vector, hrp)
+		}
+		encoded, err := EncodeBech32m(hrp, data)
+		if err != nil || encoded != strings.ToLower(vector) {
+			t.Errorf("EncodeBech32m(DecodeBech32m(%s)) = %s, %v", vector, encoded, err)
+		}
+	}
+}
+
+func TestBech32mInvalidVectors(t *testing.T) {
+	// BIP-350 invalid Bech32m strings
+	vectors := map[string]string{
+		"\x201xj0phk": "prefix character out of range",
+		"\x7f1g6xzxy": "prefix character out of range",
+		"\x801vctc34": "prefix character out of range",
+		"an84characterslonghumanreadablepartthatcontainsthetheexcludedcharactersbioandnumber11d6pts4": "overall max length exceeded",
+		"qyrz8wqd2c9m":  "no separator",
+		"1qyrz8wqd2c9m": "empty prefix",
+		"y1b0jsk6g":     "invalid data character",
+		"lt1igcx5c0":    "invalid data character",
+		"in1muywd":      "too short checksum",
+		"mm1crxm3i":     "invalid character in checksum",
+		"au1s5cgom":     "invalid character in checksum",
+		"M1VUXWEZ":      "checksum calculated with upper case prefix",
+		"16plkw9":       "empty prefix",
+		"1p2gdwpf":      "empty prefix",
+		// Bech32 (BIP-173) checksums aren't Bech32m
+		"a12uel5l": "bech32 checksum",
+		"abcdef1qpzry9x8gf2tvdw0s3jn54khce6mua7lmqqqxw": "bech32 checksum",
+		// Mixed case
+		"A1lqfn3a": "mixed case",
+	}
+	for vector, reason := range vectors {
+		if _, _, err := DecodeBech32m(vector); err == nil {
+			t.Errorf("DecodeBech32m(%q) accepted a string with %s", vector, reason)
+		}
+	}
+}
+
+func TestBech32mChecksumError(t *testing.T) {
+	encoded, err := EncodeBech32m("tb", bytes.Repeat([]byte{0xab}, AddressSize))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Swap the last checksum character
+	last := strings.IndexByte(bech32Charset, encoded[len(encoded)-1])
+	corrupted := encoded[:len(encoded)-1] + string(bech32Charset[(last+1)%len(bech32Charset)])
+	if _, _, err := DecodeBech32m(corrupted); !errors.Is(err, ErrBech32Checksum) {
+		t.Errorf("DecodeBech32m(%s) error = %v, want ErrBech32Checksum", corrupted, err)
+	}
+}
+
+func TestBech32mAddressRoundTrip(t *testing.T) {
+	addresses := []string{
+		"0000000000000000000000000000000000000000",
+		"6fac4d18c912343bf86fa7049364dd4e424ab9c0",
+		"9858effd232b4033e47d90003d41ec34ecaeda94",
+		"ffffffffffffffffffffffffffffffffffffffff",
+	}
+	for _, hrp := range []string{"tb", "ttb"} {
+		for _, address := range addresses {
+			hash, _ := hex.DecodeString(address)
+			encoded, err := EncodeBech32m(hrp, hash)
+			if err != nil {
+				t.Fatalf("EncodeBech32m(%s, %s): %v", hrp, address, err)
+			}
+			if !strings.HasPrefix(encoded, hrp+"1") {
+				t.Errorf("EncodeBech32m(%s, %s) = %s", hrp, address, encoded)
+			}
+			// Upper case strings are valid too
+			for _, input := range []string{encoded, strings.ToUpper(encoded)} {
+				decodedHRP, decoded, err := DecodeBech32m(input)
+				if err != nil || decodedHRP != hrp || !bytes.Equal(decoded, hash) {
+					t.Errorf("DecodeBech32m(%s) = %s, %x, %v, want %s, %s", input, decodedHRP, decoded, err, hrp, address)
+				}
+			}
+		}
+	}
+}
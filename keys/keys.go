@@ -0,0 +1,160 @@
+// keys.go
+package keys
+
+import (
+	"crypto/ecdsa"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"golang.org/x/crypto/sha3"
+)
+
+// Fixed encoding sizes. Public keys are stored and hashed as X || Y with
+// both coordinates padded to 32 bytes, without the 0x04 prefix.
+const (
+	PrivateKeySize = 32
+	PublicKeySize  = 64
+	AddressSize    = 20
+)
+
+// ErrKeyOutOfRange is returned for private keys that are 0 or not below the curve order
+var ErrKeyOutOfRange = errors.New("invalid private key: key out of range")
+
+// Errors of VerifyAddressChecksum
+var (
+	ErrAddressChecksum   = errors.New("address checksum is invalid")
+	ErrAddressNoChecksum = errors.New("address has no checksum")
+)
+
+// PrivateKeyFromBytes builds a secp256k1 private key from its big-endian scalar
+func PrivateKeyFromBytes(privateKeyBytes []byte) (*ecdsa.PrivateKey, error) {
+	curve := btcec.S256()
+	privateKeyD := new(big.Int).SetBytes(privateKeyBytes)
+	if privateKeyD.Sign() <= 0 || privateKeyD.Cmp(curve.Params().N) >= 0 {
+		return nil, ErrKeyOutOfRange
+	}
+	privateKey := &ecdsa.PrivateKey{
+		D: privateKeyD,
+		PublicKey: ecdsa.PublicKey{
+			Curve: curve,
+		},
+	}
+	privateKey.PublicKey.X, privateKey.PublicKey.Y = curve.ScalarBaseMult(PrivateKeyBytes(privateKey))
+	return privateKey, nil
+}
+
+// PrivateKeyFromHex builds a private key from hex, an optional 0x prefix is allowed
+func PrivateKeyFromHex(privateKeyHex string) (*ecdsa.PrivateKey, error) {
+	privateKeyBytes, err := hex.DecodeString(strings.TrimPrefix(privateKeyHex, "0x"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid private key hex: %w", err)
+	}
+	return PrivateKeyFromBytes(privateKeyBytes)
+}
+
+// PrivateKeyBytes returns the private key scalar padded to 32 bytes
+func PrivateKeyBytes(privateKey *ecdsa.PrivateKey) []byte {
+	return privateKey.D.FillBytes(make([]byte, PrivateKeySize))
+}
+
+// PrivateKeyHex returns the 64 character hex of the private key
+func PrivateKeyHex(privateKey *ecdsa.PrivateKey) string {
+	return hex.EncodeToString(PrivateKeyBytes(privateKey))
+}
+
+// PublicKeyBytes returns X || Y with both coordinates padded to 32 bytes
+func PublicKeyBytes(publicKey *ecdsa.PublicKey) []byte {
+	publicKeyBytes := make([]byte, PublicKeySize)
+	publicKey.X.FillBytes(publicKeyBytes[:32])
+	publicKey.Y.FillBytes(publicKeyBytes[32:])
+	return publicKeyBytes
+}
+
+// CompressedPublicKeyBytes returns the 33 byte SEC1 compressed public key
+func CompressedPublicKeyBytes(publicKey *ecdsa.PublicKey) []byte {
+	compressed := make([]byte, 33)
+	compressed[0] = 0x02 + byte(publicKey.Y.Bit(0))
+	publicKey.X.FillBytes(compressed[1:])
+	return compressed
+}
+
+// ParsePublicKey parses a 64 byte X || Y key, a 65 byte 0x04 prefixed key or a
+// 33 byte compressed key and checks that the point is on the curve
+func ParsePublicKey(publicKeyBytes []byte) (*ecdsa.PublicKey, error) {
+	if len(publicKeyBytes) == PublicKeySize {
+		publicKeyBytes = append([]byte{0x04}, publicKeyBytes...)
+	}
+	publicKey, err := btcec.ParsePubKey(publicKeyBytes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid public key: %w", err)
+	}
+	return publicKey.ToECDSA(), nil
+}
+
+// Address returns the 0x hex address, the last 20 bytes of the Keccak-256
+// hash of the padded public key. It matches go-ethereum crypto.PubkeyToAddress.
+func Address(publicKey *ecdsa.PublicKey) string {
+	hash := sha3.NewLegacyKeccak256()
+	hash.Write(PublicKeyBytes(publicKey))
+	return "0x" + hex.EncodeToString(hash.Sum(nil)[32-AddressSize:])
+}
+
+// AddressFromPublicKey returns the address of an encoded public key
+func AddressFromPublicKey(publicKeyBytes []byte) (string, error) {
+	publicKey, err := ParsePublicKey(publicKeyBytes)
+	if err != nil {
+		return "", err
+	}
+	return Address(publicKey), nil
+}
+
+// LegacyAddress returns the address older versions computed by hashing the
+// coordinates without padding. It differs from Address when X or Y has a
+// leading zero byte and is only used to detect wallets that need migrating.
+func LegacyAddress(publicKey *ecdsa.PublicKey) string {
+	hash := sha3.NewLegacyKeccak256()
+	hash.Write(publicKey.X.Bytes())
+	hash.Write(publicKey.Y.Bytes())
+	return "0x" + hex.EncodeToString(hash.Sum(nil)[32-AddressSize:])
+}
+
+// ChecksumAddress applies the EIP-55 mixed-case checksum to a 0x hex address. A
+// letter is upper case when the matching nibble of the Keccak-256 hash of the
+// lower case hex is 8 or more.
+func ChecksumAddress(address string) string {
+	lower := strings.ToLower(strings.TrimPrefix(address, "0x"))
+	hash := sha3.NewLegacyKeccak256()
+	hash.Write([]byte(lower))
+	addressHash := hash.Sum(nil)
+
+	checksummed := []byte(lower)
+	for i, c := range checksummed {
+		nibble := addressHash[i/2] >> 4
+		if i%2 == 1 {
+			nibble = addressHash[i/2] & 0x0f
+		}
+		if c >= 'a' && c <= 'f' && nibble >= 8 {
+			checksummed[i] = c - 'a' + 'A'
+		}
+	}
+	return "0x" + string(checksummed)
+}
+
+// VerifyAddressChecksum checks the EIP-55 checksum of a 0x hex address.
+// All lower or all upper case addresses carry no checksum and return
+// ErrAddressNoChecksum, unless they happen to be their own checksummed form.
+func VerifyAddressChecksum(address string) error {
+	checksummed := ChecksumAddress(address)
+	if address == checksummed {
+		return nil
+	}
+	hexPart := strings.TrimPrefix(address, "0x")
+	if hexPart == strings.ToLower(hexPart) || hexPart == strings.ToUpper(hexPart) {
+		return ErrAddressNoChecksum
+	}
+	return ErrAddressChecksum
+}
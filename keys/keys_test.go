@@ -0,0 +1,242 @@
+// keys_test.go
+package keys
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// The generator point G, the public key of private key 1
+const (
+	generatorX = "79be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798"
+	generatorY = "483ada7726a3c4655da4fbfc0e1108a8fd17b448a68554199c47d08ffb10d4b8"
+)
+
+func mustDecodeHex(t *testing.T, value string) []byte {
+	t.Helper()
+	decoded, err := hex.DecodeString(value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return decoded
+}
+
+func TestAddressVectors(t *testing.T) {
+	tests := []struct {
+		privateKey string
+		address    string
+	}{
+		{"0000000000000000000000000000000000000000000000000000000000000001", "0x7E5F4552091A69125d5DfCb7b8C2659029395Bdf"},
+		{"0000000000000000000000000000000000000000000000000000000000000002", "0x2B5AD5c4795c026514f8317c7a215E218DcCD6cF"},
+		{"4646464646464646464646464646464646464646464646464646464646464646", "0x9d8A62f656a8d1615C1294fd71e9CFb3E4855A4F"},
+	}
+	for _, test := range tests {
+		privateKey, err := PrivateKeyFromHex(test.privateKey)
+		if err != nil {
+			t.Fatalf("PrivateKeyFromHex(%s): %v", test.privateKey, err)
+		}
+		address := Address(&privateKey.PublicKey)
+		if address != strings.ToLower(test.address) {
+			t.Errorf("Address(%s) = %s, want %s", test.privateKey, address, strings.ToLower(test.address))
+		}
+		if checksummed := ChecksumAddress(address); checksummed != test.address {
+			t.Errorf("ChecksumAddress(%s) = %s, want %s", address, checksummed, test.address)
+		}
+		fromBytes, err := AddressFromPublicKey(PublicKeyBytes(&privateKey.PublicKey))
+		if err != nil || fromBytes != address {
+			t.Errorf("AddressFromPublicKey(%s) = %s, %v, want %s", test.privateKey, fromBytes, err, address)
+		}
+	}
+}
+
+func TestPrivateKeyPadding(t *testing.T) {
+	// A short scalar is padded to 32 bytes
+	privateKey, err := PrivateKeyFromBytes([]byte{0x01})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := strings.Repeat("00", 31) + "01"
+	if got := PrivateKeyHex(privateKey); got != want {
+		t.Errorf("PrivateKeyHex = %s, want %s", got, want)
+	}
+	if got := len(PrivateKeyBytes(privateKey)); got != PrivateKeySize {
+		t.Errorf("len(PrivateKeyBytes) = %d, want %d", got, PrivateKeySize)
+	}
+
+	// A key with leading zero bytes keeps them
+	leadingZeros := "00000000" + strings.Repeat("ab", 28)
+	privateKey, err = PrivateKeyFromHex("0x" + leadingZeros)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := PrivateKeyHex(privateKey); got != leadingZeros {
+		t.Errorf("PrivateKeyHex = %s, want %s", got, leadingZeros)
+	}
+}
+
+func TestPrivateKeyOutOfRange(t *testing.T) {
+	order := btcec.S256().Params().N
+	orderMinusOne := new(big.Int).Sub(order, big.NewInt(1))
+	tests := []struct {
+		name string
+		key  []byte
+		want error
+	}{
+		{"empty", nil, ErrKeyOutOfRange},
+		{"zero", make([]byte, 32), ErrKeyOutOfRange},
+		{"order", order.Bytes(), ErrKeyOutOfRange},
+		{"above order", bytes.Repeat([]byte{0xff}, 32), ErrKeyOutOfRange},
+		{"oversized", append([]byte{0x01}, make([]byte, 32)...), ErrKeyOutOfRange},
+		{"order - 1", orderMinusOne.Bytes(), nil},
+	}
+	for _, test := range tests {
+		_, err := PrivateKeyFromBytes(test.key)
+		if !errors.Is(err, test.want) {
+			t.Errorf("PrivateKeyFromBytes(%s) error = %v, want %v", test.name, err, test.want)
+		}
+	}
+	if _, err := PrivateKeyFromHex("0xzz"); err == nil {
+		t.Error("PrivateKeyFromHex(0xzz) returned no error")
+	}
+}
+
+func TestPublicKeySerialization(t *testing.T) {
+	privateKey, err := PrivateKeyFromBytes([]byte{0x01})
+	if err != nil {
+		t.Fatal(err)
+	}
+	publicKeyBytes := PublicKeyBytes(&privateKey.PublicKey)
+	if got := hex.EncodeToString(publicKeyBytes); got != generatorX+generatorY {
+		t.Errorf("PublicKeyBytes = %s, want %s", got, generatorX+generatorY)
+	}
+	// Y of G is even
+	compressed := CompressedPublicKeyBytes(&privateKey.PublicKey)
+	if got := hex.EncodeToString(compressed); got != "02"+generatorX {
+		t.Errorf("CompressedPublicKeyBytes = %s, want 02%s", got, generatorX)
+	}
+
+	encodings := map[string][]byte{
+		"64 bytes":   publicKeyBytes,
+		"65 bytes":   append([]byte{0x04}, publicKeyBytes...),
+		"compressed": compressed,
+	}
+	for name, encoded := range encodings {
+		publicKey, err := ParsePublicKey(encoded)
+		if err != nil {
+			t.Errorf("ParsePublicKey(%s): %v", name, err)
+			continue
+		}
+		if !bytes.Equal(PublicKeyBytes(publicKey), publicKeyBytes) {
+			t.Errorf("ParsePublicKey(%s) = %x", name, PublicKeyBytes(publicKey))
+		}
+	}
+
+	// X of G with Y + 1 is not on the curve
+	offCurve := mustDecodeHex(t, generatorX+generatorY)
+	offCurve[63]++
+	if _, err := ParsePublicKey(offCurve); err == nil {
+		t.Error("ParsePublicKey accepted a point off the curve")
+	}
+	if _, err := ParsePublicKey(publicKeyBytes[:40]); err == nil {
+		t.Error("ParsePublicKey accepted a 40 byte key")
+	}
+}
+
+// Address matches go-ethereum, including keys whose coordinates have leading zero bytes
+func TestAddressMatchesGoEthereum(t *testing.T) {
+	for i := int64(1); i <= 600; i++ {
+		privateKey, err := PrivateKeyFromBytes(big.NewInt(i).Bytes())
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := strings.ToLower(crypto.PubkeyToAddress(privateKey.PublicKey).Hex())
+		if got := Address(&privateKey.PublicKey); got != want {
+			t.Fatalf("Address(key %d) = %s, want %s", i, got, want)
+		}
+	}
+}
+
+func TestLegacyAddress(t *testing.T) {
+	privateKey, err := PrivateKeyFromBytes([]byte{0x01})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Full width coordinates hash the same with and without padding
+	if legacy, address := LegacyAddress(&privateKey.PublicKey), Address(&privateKey.PublicKey); legacy != address {
+		t.Errorf("LegacyAddress(G) = %s, want %s", legacy, address)
+	}
+
+	// Find a key with a short coordinate, about 1 in 128 keys has one
+	for i := int64(2); i < 10000; i++ {
+		privateKey, err = PrivateKeyFromBytes(big.NewInt(i).Bytes())
+		if err != nil {
+			t.Fatal(err)
+		}
+		publicKey := privateKey.PublicKey
+		if len(publicKey.X.Bytes()) == 32 && len(publicKey.Y.Bytes()) == 32 {
+			continue
+		}
+		legacy := LegacyAddress(&publicKey)
+		if legacy == Address(&publicKey) {
+			t.Fatalf("LegacyAddress(key %d) = Address, want the unpadded hash", i)
+		}
+		unpadded := append(publicKey.X.Bytes(), publicKey.Y.Bytes()...)
+		want := "0x" + hex.EncodeToString(crypto.Keccak256(unpadded)[32-AddressSize:])
+		if legacy != want {
+			t.Errorf("LegacyAddress(key %d) = %s, want %s", i, legacy, want)
+		}
+		return
+	}
+	t.Fatal("no key with a short coordinate found")
+}
+
+func TestChecksumAddress(t *testing.T) {
+	// EIP-55 test vectors
+	vectors := []string{
+		"0x52908400098527886E0F7030069857D2E4169EE7",
+		"0x8617E340B3D01FA5F11F306F4090FD50E238070D",
+		"0xde709f2102306220921060314715629080e2fb77",
+		"0x27b1fdb04752bbc536007a920d24acb045561c26",
+		"0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed",
+		"0xfB6916095ca1df60bB79Ce92cE3Ea74c37c5d359",
+		"0xdbF03B407c01E7cD3CBea99509d93f8DDDC8C6FB",
+		"0xD1220A0cf47c7B9Be7A2E6BA89F429762e7b9aDb",
+	}
+	for _, vector := range vectors {
+		if got := ChecksumAddress(strings.ToLower(vector)); got != vector {
+			t.Errorf("ChecksumAddress(%s) = %s", strings.ToLower(vector), got)
+		}
+		if got := ChecksumAddress("0x" + strings.ToUpper(vector[2:])); got != vector {
+			t.Errorf("ChecksumAddress(upper %s) = %s", vector, got)
+		}
+		// All upper or all lower case vectors are their own checksummed form
+		if err := VerifyAddressChecksum(vector); err != nil {
+			t.Errorf("VerifyAddressChecksum(%s): %v", vector, err)
+		}
+	}
+}
+
+func TestVerifyAddressChecksum(t *testing.T) {
+	tests := []struct {
+		address string
+		want    error
+	}{
+		{"0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed", nil},
+		{"0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed", ErrAddressNoChecksum},
+		{"0x5AAEB6053F3E94C9B9A09F33669435E7EF1BEAED", ErrAddressNoChecksum},
+		{"0x5AAeb6053F3E94C9b9A09f33669435E7Ef1BeAed", ErrAddressChecksum},
+		{"0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAeD", ErrAddressChecksum},
+	}
+	for _, test := range tests {
+		if err := VerifyAddressChecksum(test.address); !errors.Is(err, test.want) {
+			t.Errorf("VerifyAddressChecksum(%s) = %v, want %v", test.address, err, test.want)
+		}
+	}
+}
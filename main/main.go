@@ -2,12 +2,12 @@
 package main
 
 import (
-	"encoding/json"
 	"fmt"
 	"log"
 	"os"
 	"strconv"
 
+	"tbwallet/amounts"
 	"tbwallet/tbfunctions"
 	"tbwallet/tbwallet"
 	"tbwallet/txns"
@@ -21,6 +21,12 @@ func main() {
 		return
 	}
 
+	// Amounts are printed in the --unit unit by every command
+	if err := tbfunctions.CheckUnitFlag(); err != nil {
+		fmt.Println("Error:", err)
+		os.Exit(1)
+	}
+
 	// Capturing arguments
 	if len(os.Args) < 2 {
 		tbfunctions.NoArg()
@@ -41,6 +47,20 @@ func main() {
 					tbwallet.RecoverWallet(true, "phrase")
 				} else if SP == "-p" || SP == "--privatekey" {
 					tbwallet.RecoverWallet(true, "key")
+				} else if SP == "--shamir" {
+					tbwallet.RecoverWalletFromShamir()
+				} else if SP == "--xpub" {
+					if len(os.Args) < 4 {
+						tbfunctions.PrintRecoveryHelp()
+					} else {
+						tbwallet.RecoverWatchOnly(os.Args[3])
+					}
+				} else if SP == "-k" || SP == "--keystore" {
+					if len(os.Args) < 4 {
+						tbfunctions.PrintRecoveryHelp()
+					} else {
+						tbwallet.RecoverWalletFromKeystore(os.Args[3])
+					}
 				} else if SP == "-h" || SP == "--help" {
 					tbfunctions.PrintRecoveryHelp()
 				} else {
@@ -78,6 +98,44 @@ func main() {
 					} else {
 						tbfunctions.PrintConfigHelp()
 					}
+				} else if SP == "-rpc-url" {
+					if len(os.Args) >= 4 && os.Args[3] == "-d" {
+						tbfunctions.ShowConfig("rpc-url")
+					} else if len(os.Args) >= 5 {
+						tbfunctions.ChangeRPCURL(os.Args[3], os.Args[4])
+					} else {
+						tbfunctions.PrintConfigHelp()
+					}
+				} else if SP == "-rpc-timeout" {
+					if len(os.Args) >= 4 {
+						tbfunctions.ChangeRPCTimeout(os.Args[3])
+					} else {
+						tbfunctions.PrintConfigHelp()
+					}
+				} else if SP == "-fee-rates" {
+					if len(os.Args) >= 4 && os.Args[3] == "-d" {
+						tbfunctions.ShowConfig("fee")
+					} else if len(os.Args) >= 7 {
+						tbfunctions.ChangeFeeRates(os.Args[3], os.Args[4], os.Args[5], os.Args[6])
+					} else {
+						tbfunctions.PrintConfigHelp()
+					}
+				} else if SP == "-fee-tier" {
+					if len(os.Args) >= 4 && os.Args[3] == "-d" {
+						tbfunctions.ShowConfig("fee")
+					} else if len(os.Args) >= 4 {
+						tbfunctions.ChangeFeeTier(os.Args[3])
+					} else {
+						tbfunctions.PrintConfigHelp()
+					}
+				} else if SP == "-amount-unit" {
+					if len(os.Args) >= 4 && os.Args[3] == "-d" {
+						tbfunctions.ShowConfig("amount-unit")
+					} else if len(os.Args) >= 4 {
+						tbfunctions.ChangeAmountUnit(os.Args[3])
+					} else {
+						tbfunctions.PrintConfigHelp()
+					}
 				} else if SP == "-batch" {
 					if len(os.Args) >= 4 {
 						TP := os.Args[3]
@@ -95,12 +153,47 @@ func main() {
 					tbfunctions.PrintConfigHelp()
 				}
 			}
-		} else if FP == "address" {
-			address, isFound := tbfunctions.ShowWalletInfo("address")
-			if !isFound {
+		} else if FP == "backup" {
+			if len(os.Args) >= 3 && os.Args[2] == "shamir" {
+				tbwallet.BackupShamir()
+			} else {
+				tbfunctions.PrintBackupHelp()
+			}
+		} else if FP == "export" {
+			if tbfunctions.HasFlag("--xpub") {
+				tbwallet.ExportXPub()
 				return
 			}
-			fmt.Println("Wallet Address:", address)
+			keystorePath, isGiven := tbfunctions.FlagValue("-k", "--keystore")
+			if !isGiven {
+				tbfunctions.PrintExportHelp()
+				return
+			}
+			kdf, isGiven := tbfunctions.FlagValue("--kdf")
+			if !isGiven {
+				kdf = "scrypt"
+			}
+			tbwallet.ExportKeystore(keystorePath, kdf)
+		} else if FP == "vanity" {
+			if tbfunctions.HasFlag("-h", "--help") {
+				tbfunctions.PrintVanityHelp()
+			} else {
+				tbwallet.GenerateVanityWallet()
+			}
+		} else if FP == "balance" {
+			tbwallet.ShowBalance()
+		} else if FP == "history" {
+			txns.ShowHistory()
+		} else if FP == "nonce" {
+			startNonceCommand()
+		} else if FP == "contacts" {
+			tbfunctions.ManageContacts()
+		} else if FP == "migrate" {
+			tbwallet.MigrateWallet()
+		} else if FP == "encrypt" {
+			tbfunctions.EncryptLegacyWallet()
+		} else if FP == "address" {
+			tbwallet.ShowAddress()
 		} else if FP == "pubkey" {
 			pubkey, isFound := tbfunctions.ShowWalletInfo("pubkey")
 			if !isFound {
@@ -114,6 +207,38 @@ func main() {
 				SP := os.Args[2]
 				if SP == "-h" || SP == "--help" {
 					tbfunctions.PrintTxnHelp()
+				} else if SP == "build" {
+					fromAccount, fromIndex, err := tbwallet.ParseDerivationFlags("--from-index")
+					if err != nil {
+						fmt.Println("Error:", err)
+					} else if !txns.BuildTxnFile(fromAccount, fromIndex) {
+						os.Exit(1)
+					}
+				} else if SP == "estimate" {
+					fromAccount, fromIndex, err := tbwallet.ParseDerivationFlags("--from-index")
+					if err != nil {
+						fmt.Println("Error:", err)
+					} else if !txns.EstimateTxnFees(fromAccount, fromIndex) {
+						os.Exit(1)
+					}
+				} else if SP == "sign" || SP == "broadcast" {
+					args := tbfunctions.PositionalArgs("--out", "--max-fee", "--unit")
+					if len(args) != 4 {
+						tbfunctions.PrintTxnHelp()
+					} else if SP == "sign" && !txns.SignTxnFile(args[3]) {
+						os.Exit(1)
+					} else if SP == "broadcast" {
+						if exitCode := txns.BroadcastTxnFile(args[3]); exitCode != 0 {
+							os.Exit(exitCode)
+						}
+					}
+				} else if SP == "verify" {
+					args := tbfunctions.PositionalArgs("--unit")
+					if len(args) != 4 {
+						tbfunctions.PrintTxnHelp()
+					} else if !txns.VerifyTxnFile(args[3]) {
+						os.Exit(1)
+					}
 				} else {
 					startTxnsProcess()
 				}
@@ -137,105 +262,123 @@ func main() {
 }
 
 func startTxnsProcess() {
-	isError, isInputsVerified, dataMap := txns.VerifyTxnInputs()
-	if !isInputsVerified && isError != "" {
-		fmt.Println(isError)
-		return
-
-	} else if !isInputsVerified && isError == "" {
-		fmt.Println("Transaction inputs cannot be verified")
-		return
-	}
-	// amount converted back to int
-	amount, err := strconv.Atoi(dataMap["amount_hb"])
+	fromAccount, fromIndex, err := tbwallet.ParseDerivationFlags("--from-index")
 	if err != nil {
-		fmt.Println("Error converting amount_hb to int:", err)
+		fmt.Println("Error:", err)
 		return
 	}
-	isTxnVerified, returnError, txnMap := txns.VerifyTxn(dataMap["rec_address"], dataMap["tx_data"], amount)
-	if !isTxnVerified && returnError != "" {
-		fmt.Println(returnError)
-		return
-	} else if !isTxnVerified && returnError == "" {
-		fmt.Println("Transaction verification failed")
+	// Watch-only wallets can't sign, save the transaction for the offline wallet instead
+	if tbwallet.IsWatchOnly() {
+		if !txns.BuildTxnFile(fromAccount, fromIndex) {
+			os.Exit(txns.ExitTxnError)
+		}
 		return
 	}
-	x_sAddress := txnMap["tx_sAddress"]
-	tx_amount := txnMap["tx_amount"]
-	tx_nonce := txnMap["tx_nonce"]
-	tx_rAddress := txnMap["tx_raddress"]
-	tx_data := txnMap["tx_data"]
-	tx_folder := txnMap["txnFolder"]
+	txnFile, tx_folder, isBuilt := txns.PrepareTxn(fromAccount, fromIndex)
+	if !isBuilt {
+		os.Exit(txns.ExitTxnError)
+	}
 	txJsonFile := tx_folder + "/txn.json"
 
-	isTxSigned, newTxnMap := txns.SignTxn(x_sAddress, tx_amount, tx_nonce, tx_rAddress, tx_data)
-	if !isTxSigned {
-		return
+	// Show the contact label next to the address it resolved to
+	recipient := tbfunctions.DisplayAddress(txnFile.Transaction.Receiver)
+	if txnFile.Context.RecipientLabel != "" {
+		recipient = "@" + txnFile.Context.RecipientLabel + " (" + recipient + ")"
 	}
+
+	isTxSigned, tx := txns.SignTxn(txnFile.Transaction, fromAccount, fromIndex)
 	if !isTxSigned {
 		fmt.Println("Failed to sign the transaction")
+		txns.ReleaseNonce(txnFile.Network, tx.Sender, tx.Nonce, "")
 		return
 	}
-	file, err := os.Create(txJsonFile)
+	err = txns.UpdateNonce(txnFile.Network, tx.Sender, tx.Nonce, tx.Hash, txns.NonceSigned)
 	if err != nil {
-		fmt.Println("Error creating transaction file:", err)
-		return
-	}
-	defer file.Close()
-
-	encoder := json.NewEncoder(file)
-	encoder.SetIndent("", "  ")
-	if err := encoder.Encode(newTxnMap); err != nil {
-		fmt.Println("Error encoding transaction to JSON:", err)
-		return
+		fmt.Println("Error updating nonce ledger:", err)
 	}
-
-	_, err = file.Stat()
-	if err != nil {
-		fmt.Println("Error getting file info:", err)
-		return
-	}
-	transactionFees := newTxnMap["f"]
-	file, err = os.Create(txJsonFile)
+	txnFile.Transaction = tx
+	txnFile.Status = txns.TxnStatusSigned
+	txnFile.Description = "Signed Tulobyte transaction, broadcast it with: tbwallet txn broadcast <file>"
+	err = txns.SaveTxnFile(txJsonFile, txnFile)
 	if err != nil {
 		fmt.Println("Error creating transaction file:", err)
 		return
 	}
-	defer file.Close()
-
-	encoder = json.NewEncoder(file)
-	if err := encoder.Encode(newTxnMap); err != nil {
-		fmt.Println("Error encoding transaction to JSON:", err)
-		return
-	}
-	// Transaction size is larger then 1KB or equals to 1KB
 	printOutLine := `
   +-----------------------------------+
   |  Transaction Signed Successfully  |                                                                       
   +-----------------------------------+
 
-  Hash : ` + newTxnMap["h"] + `                                                                                                               
-  Estimated Fees : ` + transactionFees + ` Hanas                                                                                                  
+  Recipient : ` + recipient + `
+  Hash : ` + tx.Hash + `                                                                                                               
+  Size : ` + strconv.Itoa(tx.SignedSize()) + ` bytes
+  Fees : ` + amounts.FormatUint(tx.Fee, tbfunctions.DisplayUnit()) + `
 `
 	fmt.Println(printOutLine)
 	var isBroadCast string
 	fmt.Print("  Broadcast Transaction (Y/N): ")
 	_, err = fmt.Scanln(&isBroadCast)
 	if err != nil {
+		// The signed file is discarded with its nonce, it was never confirmed
+		if discardErr := txns.DiscardSignedTxn(txnFile, txJsonFile); discardErr != nil {
+			fmt.Println("Error discarding the transaction:", discardErr)
+		}
 		log.Fatal("Error reading input:", err)
 	}
 
 	if isBroadCast == "Y" || isBroadCast == "y" {
-		printOutLine := `
-  +----------------------------+
-  |  Transaction Broadcasted   |                                                                       
-  +----------------------------+`
-		fmt.Println(printOutLine)
+		if exitCode := txns.BroadcastTxn(txnFile, tx_folder); exitCode != 0 {
+			os.Exit(exitCode)
+		}
 	} else {
 		printOutLine := `
   +-------------------------+
   |  Transaction Declined   |                                                                       
   +-------------------------+`
 		fmt.Println(printOutLine)
+		// A declined txn.json left on disk could still be broadcast with a reused nonce
+		err = txns.DiscardSignedTxn(txnFile, txJsonFile)
+		if err != nil {
+			fmt.Println("Error discarding the transaction:", err)
+			fmt.Println("Broadcast it with: tbwallet txn broadcast " + txJsonFile)
+		} else {
+			fmt.Println("  The signed transaction was deleted and its nonce freed")
+		}
+	}
+}
+
+// startNonceCommand runs "nonce show|reset" for the wallet address selected by --account and --index
+func startNonceCommand() {
+	args := tbfunctions.PositionalArgs("--account", "--index", "--network")
+	if len(args) != 3 || (args[2] != "show" && args[2] != "reset") {
+		tbfunctions.PrintNonceHelp()
+		return
+	}
+	config, err := tbfunctions.LoadConfig()
+	if err != nil {
+		fmt.Println("Error loading config:", err)
+		return
+	}
+	network, isGiven := tbfunctions.FlagValue("--network")
+	if !isGiven {
+		network = config.Network
+	} else if _, ok := tbfunctions.NetworkHRPs[network]; !ok {
+		fmt.Println("Error: unknown network, use mainnet or testnet:", network)
+		return
+	}
+	account, index, err := tbwallet.ParseDerivationFlags("--index")
+	if err != nil {
+		fmt.Println("Error:", err)
+		return
+	}
+	address, _, err := tbwallet.WalletAddress(account, index)
+	if err != nil {
+		fmt.Println("Error:", err)
+		return
+	}
+	if args[2] == "show" {
+		txns.ShowNonces(network, address)
+	} else {
+		txns.ResetNonces(network, address)
 	}
 }
@@ -0,0 +1,56 @@
+// account.go
+package rpcclient
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// Block tags for account queries
+const (
+	TagLatest  = "latest"
+	TagPending = "pending"
+)
+
+// parseQuantity decodes a 0x prefixed hex quantity
+func parseQuantity(quantity string) (*big.Int, error) {
+	digits, hasPrefix := strings.CutPrefix(quantity, "0x")
+	if !hasPrefix || digits == "" {
+		return nil, fmt.Errorf("%w: quantity %q is not 0x hex", ErrInvalidResponse, quantity)
+	}
+	value, ok := new(big.Int).SetString(digits, 16)
+	if !ok {
+		return nil, fmt.Errorf("%w: quantity %q is not 0x hex", ErrInvalidResponse, quantity)
+	}
+	return value, nil
+}
+
+// GetBalance returns the balance in Hanas of address at the block tag
+func (c *Client) GetBalance(ctx context.Context, address string, tag string) (*big.Int, error) {
+	var quantity string
+	err := c.Call(ctx, &quantity, "tb_getBalance", address, tag)
+	if err != nil {
+		return nil, err
+	}
+	return parseQuantity(quantity)
+}
+
+// GetTransactionCount returns the number of transactions sent by address at
+// the block tag, which is the nonce of its next transaction
+func (c *Client) GetTransactionCount(ctx context.Context, address string, tag string) (uint64, error) {
+	var quantity string
+	err := c.Call(ctx, &quantity, "tb_getTransactionCount", address, tag)
+	if err != nil {
+		return 0, err
+	}
+	count, err := parseQuantity(quantity)
+	if err != nil {
+		return 0, err
+	}
+	if !count.IsUint64() {
+		return 0, fmt.Errorf("%w: transaction count %s out of range", ErrInvalidResponse, count)
+	}
+	return count.Uint64(), nil
+}
@@ -0,0 +1,49 @@
+// account_test.go
+package rpcclient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestGetBalance(t *testing.T) {
+	client := newTestNode(t, func(w http.ResponseWriter, call request) {
+		if call.Method != "tb_getBalance" || len(call.Params) != 2 || call.Params[1] != TagLatest {
+			t.Errorf("request = %+v", call)
+		}
+		reply(t, w, http.StatusOK, map[string]interface{}{"jsonrpc": "2.0", "id": call.ID, "result": "0x1000000000000000000000000"})
+	})
+	balance, err := client.GetBalance(context.Background(), "0x6fac4d18c912343bf86fa7049364dd4e424ab9c0", TagLatest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if balance.String() != "79228162514264337593543950336" {
+		t.Errorf("GetBalance = %s", balance)
+	}
+}
+
+func TestGetTransactionCount(t *testing.T) {
+	tests := []struct {
+		result string
+		want   uint64
+		err    error
+	}{
+		{"0x0", 0, nil},
+		{"0x2a", 42, nil},
+		{"42", 0, ErrInvalidResponse},
+		{"0x", 0, ErrInvalidResponse},
+		{"0xzz", 0, ErrInvalidResponse},
+		{"0x10000000000000000", 0, ErrInvalidResponse},
+	}
+	for _, test := range tests {
+		client := newTestNode(t, func(w http.ResponseWriter, call request) {
+			reply(t, w, http.StatusOK, map[string]interface{}{"jsonrpc": "2.0", "id": call.ID, "result": test.result})
+		})
+		count, err := client.GetTransactionCount(context.Background(), "0x6fac4d18c912343bf86fa7049364dd4e424ab9c0", TagPending)
+		if count != test.want || !errors.Is(err, test.err) {
+			t.Errorf("GetTransactionCount(%s) = %d, %v, want %d, %v", test.result, count, err, test.want, test.err)
+		}
+	}
+}
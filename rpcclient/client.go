@@ -0,0 +1,139 @@
+// client.go
+package rpcclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// Maximum size of a node response that is read
+const maxResponseSize = 10 << 20
+
+// Errors of Call, they wrap the underlying error so errors.Is works on them
+var (
+	ErrTimeout         = errors.New("node did not answer in time")
+	ErrUnreachable     = errors.New("node is unreachable")
+	ErrInvalidResponse = errors.New("invalid node response")
+)
+
+// RPCError is an error returned by the node in the JSON-RPC error object
+type RPCError struct {
+	Code    int             `json:"code"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data,omitempty"`
+}
+
+func (e *RPCError) Error() string {
+	return fmt.Sprintf("node error %d: %s", e.Code, e.Message)
+}
+
+// HTTPError is returned when the node answers with a non 200 status and no JSON-RPC error
+type HTTPError struct {
+	StatusCode int
+	Status     string
+}
+
+func (e *HTTPError) Error() string {
+	return "node returned HTTP " + e.Status
+}
+
+// Client calls JSON-RPC 2.0 methods on a node over HTTP
+type Client struct {
+	Endpoint   string
+	HTTPClient *http.Client
+	lastID     atomic.Uint64
+}
+
+// NewClient returns a client for the endpoint URL, every call is limited to timeout
+func NewClient(endpoint string, timeout time.Duration) *Client {
+	return &Client{
+		Endpoint:   endpoint,
+		HTTPClient: &http.Client{Timeout: timeout},
+	}
+}
+
+type request struct {
+	JSONRPC string        `json:"jsonrpc"`
+	ID      uint64        `json:"id"`
+	Method  string        `json:"method"`
+	Params  []interface{} `json:"params"`
+}
+
+type response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      uint64          `json:"id"`
+	Result  json.RawMessage `json:"result"`
+	Error   *RPCError       `json:"error"`
+}
+
+// Call invokes method with params and decodes the result into result, which
+// may be nil when the result isn't needed
+func (c *Client) Call(ctx context.Context, result interface{}, method string, params ...interface{}) error {
+	if params == nil {
+		params = []interface{}{}
+	}
+	id := c.lastID.Add(1)
+	body, err := json.Marshal(request{JSONRPC: "2.0", ID: id, Method: method, Params: params})
+	if err != nil {
+		return err
+	}
+	httpRequest, err := http.NewRequestWithContext(ctx, http.MethodPost, c.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrUnreachable, err)
+	}
+	httpRequest.Header.Set("Content-Type", "application/json")
+
+	httpResponse, err := c.HTTPClient.Do(httpRequest)
+	if err != nil {
+		return transportError(err)
+	}
+	defer httpResponse.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(httpResponse.Body, maxResponseSize))
+	if err != nil {
+		return transportError(err)
+	}
+
+	var rpcResponse response
+	if err := json.Unmarshal(data, &rpcResponse); err != nil {
+		if httpResponse.StatusCode != http.StatusOK {
+			return &HTTPError{StatusCode: httpResponse.StatusCode, Status: httpResponse.Status}
+		}
+		return fmt.Errorf("%w: %v", ErrInvalidResponse, err)
+	}
+	if rpcResponse.Error != nil {
+		return rpcResponse.Error
+	}
+	if httpResponse.StatusCode != http.StatusOK {
+		return &HTTPError{StatusCode: httpResponse.StatusCode, Status: httpResponse.Status}
+	}
+	if rpcResponse.ID != id {
+		return fmt.Errorf("%w: response id %d for request %d", ErrInvalidResponse, rpcResponse.ID, id)
+	}
+	if rpcResponse.Result == nil {
+		return fmt.Errorf("%w: no result", ErrInvalidResponse)
+	}
+	if result == nil {
+		return nil
+	}
+	if err := json.Unmarshal(rpcResponse.Result, result); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidResponse, err)
+	}
+	return nil
+}
+
+// transportError sorts HTTP client errors into ErrTimeout and ErrUnreachable
+func transportError(err error) error {
+	var netError net.Error
+	if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netError) && netError.Timeout()) {
+		return fmt.Errorf("%w: %v", ErrTimeout, err)
+	}
+	return fmt.Errorf("%w: %v", ErrUnreachable, err)
+}
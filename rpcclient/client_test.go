@@ -0,0 +1,177 @@
+// client_test.go
+package rpcclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// newTestNode starts a node that answers every call with handler and returns a client for it
+func newTestNode(t *testing.T, handler func(w http.ResponseWriter, call request)) *Client {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var call request
+		if err := json.NewDecoder(r.Body).Decode(&call); err != nil {
+			t.Errorf("node got an invalid request: %v", err)
+		}
+		handler(w, call)
+	}))
+	t.Cleanup(server.Close)
+	return NewClient(server.URL, time.Second)
+}
+
+// reply writes a JSON-RPC response with the status code
+func reply(t *testing.T, w http.ResponseWriter, status int, body map[string]interface{}) {
+	t.Helper()
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestCallSuccess(t *testing.T) {
+	client := newTestNode(t, func(w http.ResponseWriter, call request) {
+		if call.JSONRPC != "2.0" || call.Method != "tb_echo" {
+			t.Errorf("request = %+v", call)
+		}
+		reply(t, w, http.StatusOK, map[string]interface{}{"jsonrpc": "2.0", "id": call.ID, "result": call.Params})
+	})
+	var result []string
+	err := client.Call(context.Background(), &result, "tb_echo", "a", "b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result) != 2 || result[0] != "a" || result[1] != "b" {
+		t.Errorf("result = %v", result)
+	}
+
+	// A call without params sends an empty list, a nil result skips decoding
+	client = newTestNode(t, func(w http.ResponseWriter, call request) {
+		if call.Params == nil || len(call.Params) != 0 {
+			t.Errorf("params = %v, want []", call.Params)
+		}
+		reply(t, w, http.StatusOK, map[string]interface{}{"jsonrpc": "2.0", "id": call.ID, "result": true})
+	})
+	if err := client.Call(context.Background(), nil, "tb_ping"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestCallRPCError(t *testing.T) {
+	client := newTestNode(t, func(w http.ResponseWriter, call request) {
+		reply(t, w, http.StatusOK, map[string]interface{}{
+			"jsonrpc": "2.0",
+			"id":      call.ID,
+			"error":   map[string]interface{}{"code": -32601, "message": "method not found"},
+		})
+	})
+	err := client.Call(context.Background(), nil, "tb_missing")
+	var rpcError *RPCError
+	if !errors.As(err, &rpcError) {
+		t.Fatalf("error = %v, want *RPCError", err)
+	}
+	if rpcError.Code != -32601 || rpcError.Message != "method not found" {
+		t.Errorf("RPCError = %+v", rpcError)
+	}
+
+	// A JSON-RPC error wins over the HTTP status it comes with
+	client = newTestNode(t, func(w http.ResponseWriter, call request) {
+		reply(t, w, http.StatusInternalServerError, map[string]interface{}{
+			"jsonrpc": "2.0",
+			"id":      call.ID,
+			"error":   map[string]interface{}{"code": -32000, "message": "internal"},
+		})
+	})
+	err = client.Call(context.Background(), nil, "tb_fail")
+	if !errors.As(err, &rpcError) || rpcError.Code != -32000 {
+		t.Errorf("error = %v, want *RPCError -32000", err)
+	}
+}
+
+func TestCallHTTPError(t *testing.T) {
+	statuses := []int{http.StatusNotFound, http.StatusServiceUnavailable}
+	for _, status := range statuses {
+		client := newTestNode(t, func(w http.ResponseWriter, call request) {
+			http.Error(w, "unavailable", status)
+		})
+		err := client.Call(context.Background(), nil, "tb_ping")
+		var httpError *HTTPError
+		if !errors.As(err, &httpError) || httpError.StatusCode != status {
+			t.Errorf("status %d: error = %v, want *HTTPError", status, err)
+		}
+	}
+
+	// A valid response with a non 200 status is still an HTTP error
+	client := newTestNode(t, func(w http.ResponseWriter, call request) {
+		reply(t, w, http.StatusBadGateway, map[string]interface{}{"jsonrpc": "2.0", "id": call.ID, "result": "0x1"})
+	})
+	var httpError *HTTPError
+	if err := client.Call(context.Background(), nil, "tb_ping"); !errors.As(err, &httpError) {
+		t.Errorf("error = %v, want *HTTPError", err)
+	}
+}
+
+func TestCallInvalidResponse(t *testing.T) {
+	tests := map[string]func(w http.ResponseWriter, call request){
+		"malformed JSON": func(w http.ResponseWriter, call request) {
+			w.Write([]byte(`{"jsonrpc":"2.0","id":`))
+		},
+		"not JSON": func(w http.ResponseWriter, call request) {
+			w.Write([]byte("<html>node</html>"))
+		},
+		"wrong id": func(w http.ResponseWriter, call request) {
+			reply(t, w, http.StatusOK, map[string]interface{}{"jsonrpc": "2.0", "id": call.ID + 1, "result": "0x1"})
+		},
+		"no result": func(w http.ResponseWriter, call request) {
+			reply(t, w, http.StatusOK, map[string]interface{}{"jsonrpc": "2.0", "id": call.ID})
+		},
+		"result type": func(w http.ResponseWriter, call request) {
+			reply(t, w, http.StatusOK, map[string]interface{}{"jsonrpc": "2.0", "id": call.ID, "result": 12})
+		},
+	}
+	for name, handler := range tests {
+		client := newTestNode(t, handler)
+		var result string
+		if err := client.Call(context.Background(), &result, "tb_ping"); !errors.Is(err, ErrInvalidResponse) {
+			t.Errorf("%s: error = %v, want ErrInvalidResponse", name, err)
+		}
+	}
+}
+
+func TestCallTimeout(t *testing.T) {
+	client := newTestNode(t, func(w http.ResponseWriter, call request) {
+		time.Sleep(200 * time.Millisecond)
+	})
+	client.HTTPClient.Timeout = 20 * time.Millisecond
+	if err := client.Call(context.Background(), nil, "tb_slow"); !errors.Is(err, ErrTimeout) {
+		t.Errorf("client timeout: error = %v, want ErrTimeout", err)
+	}
+
+	client.HTTPClient.Timeout = time.Second
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := client.Call(ctx, nil, "tb_slow"); !errors.Is(err, ErrTimeout) {
+		t.Errorf("context deadline: error = %v, want ErrTimeout", err)
+	}
+}
+
+func TestCallUnreachable(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	endpoint := server.URL
+	server.Close()
+	client := NewClient(endpoint, time.Second)
+	if err := client.Call(context.Background(), nil, "tb_ping"); !errors.Is(err, ErrUnreachable) {
+		t.Errorf("closed node: error = %v, want ErrUnreachable", err)
+	}
+
+	client = NewClient("://no-scheme", time.Second)
+	if err := client.Call(context.Background(), nil, "tb_ping"); !errors.Is(err, ErrUnreachable) {
+		t.Errorf("invalid endpoint: error = %v, want ErrUnreachable", err)
+	}
+}
@@ -0,0 +1,46 @@
+// transaction.go
+package rpcclient
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"strings"
+)
+
+// Node rejections of a sent transaction, RPCError matches them with errors.Is
+var (
+	ErrTxnKnown     = errors.New("transaction is already known")
+	ErrNonceTooLow  = errors.New("nonce too low")
+	ErrNonceTooHigh = errors.New("nonce too high")
+)
+
+// rejectionMessages maps node error messages to the rejection they report
+var rejectionMessages = map[string]error{
+	"already known":     ErrTxnKnown,
+	"known transaction": ErrTxnKnown,
+	"nonce too low":     ErrNonceTooLow,
+	"nonce too high":    ErrNonceTooHigh,
+}
+
+// Is lets errors.Is match a node error against ErrTxnKnown, ErrNonceTooLow and ErrNonceTooHigh
+func (e *RPCError) Is(target error) bool {
+	message := strings.ToLower(e.Message)
+	for text, rejection := range rejectionMessages {
+		if target == rejection && strings.Contains(message, text) {
+			return true
+		}
+	}
+	return false
+}
+
+// SendRawTransaction submits a signed transaction encoding and returns the
+// hash the node computed for it
+func (c *Client) SendRawTransaction(ctx context.Context, signed []byte) (string, error) {
+	var hash string
+	err := c.Call(ctx, &hash, "tb_sendRawTransaction", "0x"+hex.EncodeToString(signed))
+	if err != nil {
+		return "", err
+	}
+	return hash, nil
+}
@@ -0,0 +1,69 @@
+// transaction_test.go
+package rpcclient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestSendRawTransaction(t *testing.T) {
+	client := newTestNode(t, func(w http.ResponseWriter, call request) {
+		if call.Method != "tb_sendRawTransaction" || len(call.Params) != 1 || call.Params[0] != "0x0102ff" {
+			t.Errorf("request = %+v", call)
+		}
+		reply(t, w, http.StatusOK, map[string]interface{}{"jsonrpc": "2.0", "id": call.ID, "result": "0xabc"})
+	})
+	hash, err := client.SendRawTransaction(context.Background(), []byte{0x01, 0x02, 0xff})
+	if err != nil || hash != "0xabc" {
+		t.Errorf("SendRawTransaction = %s, %v, want 0xabc", hash, err)
+	}
+}
+
+func TestSendRawTransactionRejections(t *testing.T) {
+	tests := []struct {
+		message string
+		want    error
+	}{
+		{"already known", ErrTxnKnown},
+		{"Known transaction: 0xabc", ErrTxnKnown},
+		{"nonce too low: next nonce 4, tx nonce 2", ErrNonceTooLow},
+		{"Nonce Too High", ErrNonceTooHigh},
+	}
+	rejections := []error{ErrTxnKnown, ErrNonceTooLow, ErrNonceTooHigh}
+	for _, test := range tests {
+		client := newTestNode(t, func(w http.ResponseWriter, call request) {
+			reply(t, w, http.StatusOK, map[string]interface{}{
+				"jsonrpc": "2.0",
+				"id":      call.ID,
+				"error":   map[string]interface{}{"code": -32000, "message": test.message},
+			})
+		})
+		_, err := client.SendRawTransaction(context.Background(), []byte{0x01})
+		for _, rejection := range rejections {
+			if got := errors.Is(err, rejection); got != (rejection == test.want) {
+				t.Errorf("%q: errors.Is(%v) = %v", test.message, rejection, got)
+			}
+		}
+		var rpcError *RPCError
+		if !errors.As(err, &rpcError) {
+			t.Errorf("%q: error = %v, want *RPCError", test.message, err)
+		}
+	}
+
+	// Other node errors match none of the rejections
+	client := newTestNode(t, func(w http.ResponseWriter, call request) {
+		reply(t, w, http.StatusOK, map[string]interface{}{
+			"jsonrpc": "2.0",
+			"id":      call.ID,
+			"error":   map[string]interface{}{"code": -32000, "message": "insufficient funds"},
+		})
+	})
+	_, err := client.SendRawTransaction(context.Background(), []byte{0x01})
+	for _, rejection := range rejections {
+		if errors.Is(err, rejection) {
+			t.Errorf("insufficient funds matches %v", rejection)
+		}
+	}
+}
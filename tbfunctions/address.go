@@ -0,0 +1,97 @@
+// address.go
+package tbfunctions
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"tbwallet/keys"
+)
+
+// NetworkHRPs maps each network to the human-readable part of its Bech32m addresses
+var NetworkHRPs = map[string]string{
+	"mainnet": "tb",
+	"testnet": "ttb",
+}
+
+// DisplayAddress formats a wallet address for output with its EIP-55 checksum
+func DisplayAddress(address string) string {
+	return keys.ChecksumAddress(address)
+}
+
+// FormatAddress formats a 0x hex address as EIP-55 hex or as Bech32m for the configured network
+func FormatAddress(address string, format string) (string, error) {
+	switch format {
+	case "hex":
+		return DisplayAddress(address), nil
+	case "bech32":
+		config, err := LoadConfig()
+		if err != nil {
+			return "", fmt.Errorf("error loading config: %w", err)
+		}
+		return EncodeNetworkBech32Address(address, config.Network)
+	default:
+		return "", fmt.Errorf("unknown address format %q, use bech32 or hex", format)
+	}
+}
+
+// EncodeNetworkBech32Address encodes a 0x hex address as Bech32m for the given network
+func EncodeNetworkBech32Address(address string, network string) (string, error) {
+	hrp, ok := NetworkHRPs[network]
+	if !ok {
+		return "", fmt.Errorf("unknown network %q", network)
+	}
+	hash, err := hex.DecodeString(strings.TrimPrefix(strings.ToLower(address), "0x"))
+	if err != nil {
+		return "", fmt.Errorf("invalid address: %w", err)
+	}
+	return keys.EncodeBech32m(hrp, hash)
+}
+
+// IsBech32Address reports whether input looks like a Bech32m address of any network
+func IsBech32Address(input string) bool {
+	_, isBech32 := Bech32Network(input)
+	return isBech32
+}
+
+// ParseBech32Address decodes a Bech32m address to 0x hex. Addresses of another
+// network than config.Network are rejected.
+func ParseBech32Address(input string) (string, error) {
+	config, err := LoadConfig()
+	if err != nil {
+		return "", fmt.Errorf("error loading config: %w", err)
+	}
+	return ParseNetworkBech32Address(input, config.Network)
+}
+
+// ParseNetworkBech32Address decodes a Bech32m address of the given network to 0x hex
+func ParseNetworkBech32Address(input string, network string) (string, error) {
+	hrp, hash, err := keys.DecodeBech32m(input)
+	if err != nil {
+		return "", fmt.Errorf("invalid bech32 address: %w", err)
+	}
+	if hrp != NetworkHRPs[network] {
+		for addressNetwork, networkHRP := range NetworkHRPs {
+			if hrp == networkHRP {
+				return "", fmt.Errorf("%s is a %s address but the wallet is on %s", input, addressNetwork, network)
+			}
+		}
+		return "", fmt.Errorf("unknown address prefix %q", hrp)
+	}
+	if len(hash) != keys.AddressSize {
+		return "", fmt.Errorf("bech32 address must hold %d bytes, got %d", keys.AddressSize, len(hash))
+	}
+	return "0x" + hex.EncodeToString(hash), nil
+}
+
+// Bech32Network returns the network of a Bech32m address from its prefix
+func Bech32Network(input string) (string, bool) {
+	lower := strings.ToLower(input)
+	for network, hrp := range NetworkHRPs {
+		if strings.HasPrefix(lower, hrp+"1") {
+			return network, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,68 @@
+// address_test.go
+package tbfunctions
+
+import (
+	"strings"
+	"testing"
+
+	"tbwallet/keys"
+)
+
+const testAddress = "0x6fac4d18c912343bf86fa7049364dd4e424ab9c0"
+
+func TestNetworkBech32RoundTrip(t *testing.T) {
+	for network, hrp := range NetworkHRPs {
+		// Checksummed input encodes the same as lower case
+		for _, address := range []string{testAddress, DisplayAddress(testAddress)} {
+			encoded, err := EncodeNetworkBech32Address(address, network)
+			if err != nil {
+				t.Fatalf("EncodeNetworkBech32Address(%s, %s): %v", address, network, err)
+			}
+			if !strings.HasPrefix(encoded, hrp+"1") {
+				t.Errorf("EncodeNetworkBech32Address(%s, %s) = %s, want prefix %s1", address, network, encoded, hrp)
+			}
+			if got, isBech32 := Bech32Network(encoded); !isBech32 || got != network {
+				t.Errorf("Bech32Network(%s) = %s, %v, want %s", encoded, got, isBech32, network)
+			}
+			decoded, err := ParseNetworkBech32Address(encoded, network)
+			if err != nil || decoded != testAddress {
+				t.Errorf("ParseNetworkBech32Address(%s, %s) = %s, %v, want %s", encoded, network, decoded, err, testAddress)
+			}
+		}
+	}
+}
+
+func TestParseNetworkBech32AddressErrors(t *testing.T) {
+	mainnet, _ := EncodeNetworkBech32Address(testAddress, "mainnet")
+	testnet, _ := EncodeNetworkBech32Address(testAddress, "testnet")
+	foreign, _ := keys.EncodeBech32m("bc", make([]byte, keys.AddressSize))
+	short, _ := keys.EncodeBech32m("tb", make([]byte, keys.AddressSize-1))
+	corrupted := mainnet[:len(mainnet)-1] + "q"
+	if strings.HasSuffix(mainnet, "q") {
+		corrupted = mainnet[:len(mainnet)-1] + "p"
+	}
+	tests := []struct {
+		input   string
+		network string
+		want    string
+	}{
+		{testnet, "mainnet", "is a testnet address but the wallet is on mainnet"},
+		{mainnet, "testnet", "is a mainnet address but the wallet is on testnet"},
+		{foreign, "mainnet", `unknown address prefix "bc"`},
+		{short, "mainnet", "must hold 20 bytes, got 19"},
+		{corrupted, "mainnet", "invalid bech32 address"},
+	}
+	for _, test := range tests {
+		_, err := ParseNetworkBech32Address(test.input, test.network)
+		if err == nil || !strings.Contains(err.Error(), test.want) {
+			t.Errorf("ParseNetworkBech32Address(%s, %s) error = %v, want %q", test.input, test.network, err, test.want)
+		}
+	}
+
+	if _, err := EncodeNetworkBech32Address(testAddress, "regtest"); err == nil {
+		t.Error("EncodeNetworkBech32Address accepted an unknown network")
+	}
+	if _, isBech32 := Bech32Network(testAddress); isBech32 {
+		t.Errorf("Bech32Network(%s) reported a bech32 address", testAddress)
+	}
+}
@@ -0,0 +1,85 @@
+// args.go
+package tbfunctions
+
+import (
+	"os"
+
+	"tbwallet/amounts"
+)
+
+// FlagValue returns the value following any of the given flag names in os.Args
+func FlagValue(names ...string) (string, bool) {
+	for i, arg := range os.Args {
+		for _, name := range names {
+			if arg == name && i+1 < len(os.Args) {
+				return os.Args[i+1], true
+			}
+		}
+	}
+	return "", false
+}
+
+// HasFlag reports whether any of the given flag names is present in os.Args
+func HasFlag(names ...string) bool {
+	for _, arg := range os.Args {
+		for _, name := range names {
+			if arg == name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// PositionalArgs returns os.Args without flags. valueFlags lists the flags that
+// take a value, their value is skipped along with the flag.
+func PositionalArgs(valueFlags ...string) []string {
+	var args []string
+	for i := 0; i < len(os.Args); i++ {
+		arg := os.Args[i]
+		isValueFlag := false
+		for _, name := range valueFlags {
+			if arg == name {
+				isValueFlag = true
+			}
+		}
+		if isValueFlag {
+			i++
+			continue
+		}
+		if len(arg) > 2 && arg[:2] == "--" {
+			continue
+		}
+		args = append(args, arg)
+	}
+	return args
+}
+
+// CheckUnitFlag rejects an unknown --unit value
+func CheckUnitFlag() error {
+	if value, isGiven := FlagValue("--unit"); isGiven {
+		_, err := amounts.ParseUnit(value)
+		return err
+	}
+	return nil
+}
+
+// InputUnit returns the unit of an amount typed without one, the configured
+// amount unit or Hanas. It is separate from --unit, which only changes output.
+func InputUnit() string {
+	config, err := LoadConfig()
+	if err != nil {
+		return amounts.UnitHanas
+	}
+	return config.DefaultAmountUnit()
+}
+
+// DisplayUnit returns the unit amounts are printed in, --unit or TBYT
+func DisplayUnit() string {
+	if value, isGiven := FlagValue("--unit"); isGiven {
+		if unit, err := amounts.ParseUnit(value); err == nil {
+			return unit
+		}
+	}
+	return amounts.UnitTBYT
+}
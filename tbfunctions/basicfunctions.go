@@ -2,21 +2,19 @@
 package tbfunctions
 
 import (
-	"crypto/ecdsa"
 	"crypto/sha512"
 	"encoding/base64"
 	"encoding/hex"
 	"fmt"
 	"image"
 	"log"
-	"math/big"
 	"os"
 	"path/filepath"
 	"strings"
 
-	"github.com/btcsuite/btcd/btcec/v2"
+	"tbwallet/keys"
+
 	"golang.org/x/crypto/pbkdf2"
-	"golang.org/x/crypto/sha3"
 )
 
 // DeriveSeedFromMnemonic derives the seed from the mnemonic phrase
@@ -39,21 +37,54 @@ func IsValidHex(s string) bool {
 	return true
 }
 
+// SavePrivateKey encrypts the private key with a new password and writes the wallet file
 func SavePrivateKey(filename string, privateKey string) error {
+	return SaveSeedWallet(filename, privateKey, nil, "", nil)
+}
 
-	// Create the directory if it doesn't exist
-	err := os.MkdirAll(filepath.Dir(filename), 0755)
+// SaveSeedWallet encrypts the private key and the HD seed with a new password and
+// writes the wallet file. path is the derivation path of the private key and
+// accounts caches the extended public keys of its account.
+func SaveSeedWallet(filename string, privateKey string, seed []byte, path string, accounts map[string]*WalletAccount) error {
+	privateKeyBytes, err := hex.DecodeString(privateKey)
+	if err != nil {
+		return fmt.Errorf("failed to decode private key: %w", err)
+	}
+	publicKeyHex, address, err := deriveWalletInfo(privateKeyBytes)
 	if err != nil {
-		return fmt.Errorf("failed to create directory: %w", err)
+		return err
 	}
 
-	// Write the private key to the file
-	err = os.WriteFile(filename, []byte(privateKey), 0600) // 0600 for secure permissions
+	password, err := ReadNewPassword()
+	if err != nil {
+		return err
+	}
+	walletCrypto, err := EncryptSecret(privateKeyBytes, password)
 	if err != nil {
-		return fmt.Errorf("failed to write private key to file: %w", err)
+		return fmt.Errorf("failed to encrypt private key: %w", err)
 	}
 
-	fmt.Println("Private key(hex) saved to:", filename)
+	wallet := WalletFile{
+		Version:   WalletFileVersion,
+		Address:   address,
+		PublicKey: publicKeyHex,
+		Crypto:    &walletCrypto,
+	}
+	if seed != nil {
+		seedCrypto, err := EncryptSecret(seed, password)
+		if err != nil {
+			return fmt.Errorf("failed to encrypt seed: %w", err)
+		}
+		wallet.Seed = &seedCrypto
+		wallet.Path = path
+		wallet.Accounts = accounts
+	}
+	err = SaveWallet(filename, wallet)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("Encrypted wallet saved to:", filename)
 	return nil
 }
 
@@ -129,42 +160,25 @@ func InitDirs(isConfigInit bool) bool {
 
 }
 
+// ReadPrivateKey loads the wallet file and decrypts the private key, prompting for the password
 func ReadPrivateKey(filename string) ([]byte, error) {
-	// Read the file content as a byte slice
-	data, err := os.ReadFile(filename)
+	wallet, legacyKey, err := LoadWallet(filename)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read file: %w", err)
+		return nil, err
 	}
-
-	// Convert hex string to bytes (assuming file contains valid hex characters)
-	decodedData, err := hex.DecodeString(string(data))
-	if err != nil {
-		return nil, fmt.Errorf("failed to decode hex string: %w", err)
+	if legacyKey != nil {
+		printLegacyWarning()
+		return legacyKey, nil
 	}
-
-	return decodedData, nil
+	return UnlockPrivateKey(wallet)
 }
 
-// SerializePublicKeyCompressed serializes the public key in compressed format
-func CsSerializePublicKeyCompressed(pub *ecdsa.PublicKey) []byte {
-	xBytes := pub.X.Bytes()
-
-	var prefix byte
-	if pub.Y.Bit(0) == 0 {
-		// y is even
-		prefix = 0x02
-	} else {
-		// y is odd
-		prefix = 0x03
-	}
-
-	// Compress the x-coordinate to 32 bytes
-	paddedX := make([]byte, 32)
-	copy(paddedX[32-len(xBytes):], xBytes)
-
-	// The compressed public key consists of the prefix + x-coordinate
-	serializedKey := append([]byte{prefix}, paddedX...)
-	return serializedKey
+func printLegacyWarning() {
+	fmt.Println(`
++----------------------------------------------------------+
+| Warning: Wallet private key is stored unencrypted        |
+|          Run "tbwallet encrypt" to protect it            |
++----------------------------------------------------------+`)
 }
 
 func ShowWalletInfo(infoType string) (string, bool) {
@@ -184,52 +198,42 @@ func ShowWalletInfo(infoType string) (string, bool) {
 		return "", false
 	}
 
-	// Read private key from wallet file
-	privateKeyBytes, err := ReadPrivateKey(walletFilePath)
+	wallet, legacyKey, err := LoadWallet(walletFilePath)
 	if err != nil {
 		fmt.Println("Can't read wallet file")
 		return "", false
 	}
 
-	// Ensure the private key bytes are valid (at least 32 bytes long)
-	if len(privateKeyBytes) < 32 {
-		fmt.Println("Error: Private key must be at least 32 bytes long")
-		return "", false
-	}
-
-	// Step 1: Hash the private key bytes to generate a valid private key
-	privateKeyD := new(big.Int).SetBytes(privateKeyBytes[:])
-
-	// Step 2: Generate the elliptic curve (P256 curve)
-	curve := btcec.S256() // Use secp256k1
-
-	// Step 3: Ensure the private key is valid (less than curve's order and greater than 0)
-	if privateKeyD.Cmp(curve.Params().N) >= 0 || privateKeyD.Sign() <= 0 {
-		fmt.Println("Error: Invalid private key provided")
-		return "", false
-	}
-
-	// Step 4: Create the private key object
-	privateKey := &ecdsa.PrivateKey{
-		D: privateKeyD,
-		PublicKey: ecdsa.PublicKey{
-			Curve: curve,
-		},
+	// Address and public key are stored in clear, only the private key needs the password
+	if legacyKey == nil {
+		CheckAddressMigration(wallet)
+		switch infoType {
+		case "address":
+			return wallet.Address, true
+		case "pubkey":
+			return wallet.PublicKey, true
+		case "privatekey":
+			privateKeyBytes, err := UnlockPrivateKey(wallet)
+			if err != nil {
+				fmt.Println("Error:", err)
+				return "", false
+			}
+			return hex.EncodeToString(privateKeyBytes), true
+		default:
+			return "", false
+		}
 	}
 
-	// Step 5: Derive the corresponding public key (using scalar multiplication)
-	privateKey.PublicKey.X, privateKey.PublicKey.Y = curve.ScalarBaseMult(privateKey.D.Bytes())
-
-	// Step 6: Serialize the public key (compressed)
-	publicKey := SerializePublicKeyUncompressed(&privateKey.PublicKey)
-	publicKeyHex := hex.EncodeToString(publicKey)
-
-	// Step 8: Generate the Ethereum-like address using Keccak-256
-	address, err := GenerateAddress(publicKey)
+	publicKeyHex, address, err := deriveWalletInfo(legacyKey)
 	if err != nil {
-		fmt.Println("Error generating address:", err)
+		fmt.Println("Error:", err)
 		return "", false
 	}
+	// Plaintext wallets have no stored address, warn when older versions showed a different one
+	privateKey, err := keys.PrivateKeyFromBytes(legacyKey)
+	if err == nil && keys.LegacyAddress(&privateKey.PublicKey) != address {
+		printMigrationWarning(keys.LegacyAddress(&privateKey.PublicKey))
+	}
 
 	// Return the appropriate wallet information based on the infoType
 	switch infoType {
@@ -238,32 +242,23 @@ func ShowWalletInfo(infoType string) (string, bool) {
 	case "pubkey":
 		return publicKeyHex, true
 	case "privatekey":
-		return hex.EncodeToString(privateKeyBytes), true
+		printLegacyWarning()
+		return hex.EncodeToString(legacyKey), true
 	default:
 		return "", false
 	}
 }
 
-// GenerateEthereumAddress generates an Ethereum-like address using Keccak-256 hash of the public key
-func GenerateAddress(pubKey []byte) (string, error) {
-	// Perform Keccak-256 hash on the public key
-	hash := sha3.NewLegacyKeccak256()
-	hash.Write(pubKey)
-	addressHash := hash.Sum(nil)
-
-	// Ethereum address is the last 20 bytes of the Keccak-256 hash
-	address := addressHash[12:]
-
-	// Format the address as a hex string
-	tbtAddress := "0x" + hex.EncodeToString(address)
-	return tbtAddress, nil
-}
-func SerializePublicKeyUncompressed(pubKey *ecdsa.PublicKey) []byte {
-	// Uncompressed public key starts with 0x04 followed by X and Y coordinates
-	pubKeyBytes := pubKey.X.Bytes()
-	pubKeyBytes = append(pubKeyBytes, pubKey.Y.Bytes()...)
-	return pubKeyBytes
+// deriveWalletInfo computes the public key hex and address for a raw private key
+func deriveWalletInfo(privateKeyBytes []byte) (string, string, error) {
+	privateKey, err := keys.PrivateKeyFromBytes(privateKeyBytes)
+	if err != nil {
+		return "", "", err
+	}
+	publicKeyHex := hex.EncodeToString(keys.PublicKeyBytes(&privateKey.PublicKey))
+	return publicKeyHex, keys.Address(&privateKey.PublicKey), nil
 }
+
 func ShowConfig(display string) {
 	config, err := LoadConfig()
 	if err != nil {
@@ -277,11 +272,25 @@ func ShowConfig(display string) {
 		fmt.Print("Wallet Path: ", walletPath)
 	} else if display == "rpc" {
 		fmt.Println("RPC Network: ", rpcNetwork)
+	} else if display == "rpc-url" {
+		for _, network := range []string{"mainnet", "testnet"} {
+			fmt.Println("RPC URL "+network+": ", config.RPCURL(network))
+		}
+		fmt.Println("RPC Timeout: ", config.RPCTimeoutDuration())
+	} else if display == "fee" {
+		fmt.Println("Fee Tier: ", config.DefaultFeeTier())
+		for _, network := range []string{"mainnet", "testnet"} {
+			rates := config.NetworkFeeRates(network)
+			fmt.Printf("Fee Rates %s:  slow %d, normal %d, fast %d Hanas per byte\n", network, rates.Slow, rates.Normal, rates.Fast)
+		}
+	} else if display == "amount-unit" {
+		fmt.Println("Amount Unit: ", config.DefaultAmountUnit())
 	} else if display == "batch" {
+		// "0" is hunter and anything else normal, as txns.ConfigBatch reads it
 		if batchChoice == "0" {
-			fmt.Println("Batch Choice: ", "Nromal")
-		} else {
 			fmt.Println("Batch Choice: ", "Hunter")
+		} else {
+			fmt.Println("Batch Choice: ", "Normal")
 		}
 
 	}
@@ -369,6 +378,7 @@ func EncodeImageToBase64(filePath string) (string, bool) {
 	return dataURL, true
 }
 
+// GetPrivateKey decrypts the configured wallet and returns the private key hex
 func GetPrivateKey() (string, bool) {
 	// Load configuration
 	config, err := LoadConfig()
@@ -377,12 +387,12 @@ func GetPrivateKey() (string, bool) {
 		return "", false
 	}
 	walletPath := config.WalletPath
-	data, err := os.ReadFile(walletPath)
+	privateKeyBytes, err := ReadPrivateKey(walletPath)
 	if err != nil {
-		fmt.Println("Failed to read file: %w", err)
+		fmt.Println("Failed to unlock wallet:", err)
 		return "", false
 	}
-	privateKeyHex := string(data)
+	privateKeyHex := hex.EncodeToString(privateKeyBytes)
 	return privateKeyHex, true
 
 }
@@ -4,15 +4,132 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"net/url"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"time"
+
+	"tbwallet/amounts"
+	"tbwallet/rpcclient"
 )
 
 type Config struct {
 	Network    string `json:"RPCEndPoint"`
 	WalletPath string `json:"Walletpath"`
 	TxnBatch   string `json:"TxnBatch"`
+	// Node JSON-RPC URL per network, DefaultRPCURLs is used for missing networks
+	RPCURLs map[string]string `json:"RPCURLs,omitempty"`
+	// Node request timeout in seconds, 0 uses DefaultRPCTimeout
+	RPCTimeout int `json:"RPCTimeout,omitempty"`
+	// Fee tier rates per network, DefaultFeeRates is used for missing networks
+	FeeRates map[string]FeeRates `json:"FeeRates,omitempty"`
+	// Fee tier of a txn without --fee-tier or --fee-rate, empty is normal
+	FeeTier string `json:"FeeTier,omitempty"`
+	// Unit of an amount typed without one, empty is Hanas
+	AmountUnit string `json:"AmountUnit,omitempty"`
+}
+
+// DefaultRPCURLs are the JSON-RPC URLs of a node running on this machine
+var DefaultRPCURLs = map[string]string{
+	"mainnet": "http://127.0.0.1:8545",
+	"testnet": "http://127.0.0.1:18545",
+}
+
+// DefaultRPCTimeout is the node request timeout in seconds
+const DefaultRPCTimeout = 10
+
+// RPCURL returns the node URL configured for network
+func (config Config) RPCURL(network string) string {
+	if rpcURL, ok := config.RPCURLs[network]; ok {
+		return rpcURL
+	}
+	return DefaultRPCURLs[network]
+}
+
+// RPCTimeoutDuration returns the configured node request timeout
+func (config Config) RPCTimeoutDuration() time.Duration {
+	if config.RPCTimeout <= 0 {
+		return DefaultRPCTimeout * time.Second
+	}
+	return time.Duration(config.RPCTimeout) * time.Second
+}
+
+// Fee tiers of a transaction
+const (
+	FeeTierSlow   = "slow"
+	FeeTierNormal = "normal"
+	FeeTierFast   = "fast"
+)
+
+// MinFeeRate is the lowest fee rate the node accepts, in Hanas per byte
+const MinFeeRate = 10
+
+// FeeTiers lists the fee tiers from the cheapest
+var FeeTiers = []string{FeeTierSlow, FeeTierNormal, FeeTierFast}
+
+// FeeRates holds the rate of each fee tier in Hanas per byte of the signed transaction
+type FeeRates struct {
+	Slow   uint64 `json:"slow"`
+	Normal uint64 `json:"normal"`
+	Fast   uint64 `json:"fast"`
+}
+
+// Rate returns the rate of tier
+func (rates FeeRates) Rate(tier string) (uint64, bool) {
+	switch tier {
+	case FeeTierSlow:
+		return rates.Slow, true
+	case FeeTierNormal:
+		return rates.Normal, true
+	case FeeTierFast:
+		return rates.Fast, true
+	}
+	return 0, false
+}
+
+// DefaultFeeRates are the fee tier rates of each network, slow is the node's minimum
+var DefaultFeeRates = map[string]FeeRates{
+	"mainnet": {Slow: 10, Normal: 15, Fast: 25},
+	"testnet": {Slow: 10, Normal: 10, Fast: 10},
+}
+
+// NetworkFeeRates returns the fee tier rates configured for network
+func (config Config) NetworkFeeRates(network string) FeeRates {
+	if rates, ok := config.FeeRates[network]; ok {
+		return rates
+	}
+	return DefaultFeeRates[network]
+}
+
+// DefaultFeeTier returns the configured fee tier
+func (config Config) DefaultFeeTier() string {
+	if config.FeeTier == "" {
+		return FeeTierNormal
+	}
+	return config.FeeTier
+}
+
+// DefaultAmountUnit returns the unit of an amount typed without one
+func (config Config) DefaultAmountUnit() string {
+	if unit, err := amounts.ParseUnit(config.AmountUnit); err == nil {
+		return unit
+	}
+	return amounts.UnitHanas
+}
+
+// NodeClient returns a JSON-RPC client for the node of network
+func NodeClient(network string) (*rpcclient.Client, error) {
+	config, err := LoadConfig()
+	if err != nil {
+		return nil, fmt.Errorf("error loading config: %w", err)
+	}
+	rpcURL := config.RPCURL(network)
+	if rpcURL == "" {
+		return nil, fmt.Errorf("no RPC URL for network %q", network)
+	}
+	return rpcclient.NewClient(rpcURL, config.RPCTimeoutDuration()), nil
 }
 
 func CreateDefaultConfig(filename string, fileDirName string) error {
@@ -85,7 +202,138 @@ func ChangeBatch(batch string) {
 		fmt.Println("Error saving config:", err)
 		return
 	}
-	fmt.Println("Network configured to :", batchConfigured)
+	fmt.Println("Batch configured to :", batchConfigured)
+}
+
+// ChangeRPCURL sets the node URL of a network
+func ChangeRPCURL(network string, rpcURL string) {
+	if _, ok := NetworkHRPs[network]; !ok {
+		fmt.Println("Error: unknown network, use mainnet or testnet:", network)
+		return
+	}
+	parsedURL, err := url.Parse(rpcURL)
+	if err != nil || (parsedURL.Scheme != "http" && parsedURL.Scheme != "https") || parsedURL.Host == "" {
+		fmt.Println("Error: RPC URL must be an http:// or https:// URL:", rpcURL)
+		return
+	}
+	config, err := LoadConfig()
+	if err != nil {
+		fmt.Println("Error loading config:", err)
+		return
+	}
+	if config.RPCURLs == nil {
+		config.RPCURLs = map[string]string{}
+	}
+	config.RPCURLs[network] = rpcURL
+	err = SaveConfig(config)
+	if err != nil {
+		fmt.Println("Error saving config:", err)
+		return
+	}
+	fmt.Println("RPC URL of", network, "configured to :", rpcURL)
+}
+
+// ChangeRPCTimeout sets the node request timeout in seconds
+func ChangeRPCTimeout(seconds string) {
+	timeout, err := strconv.Atoi(seconds)
+	if err != nil || timeout <= 0 {
+		fmt.Println("Error: timeout must be a number of seconds:", seconds)
+		return
+	}
+	config, err := LoadConfig()
+	if err != nil {
+		fmt.Println("Error loading config:", err)
+		return
+	}
+	config.RPCTimeout = timeout
+	err = SaveConfig(config)
+	if err != nil {
+		fmt.Println("Error saving config:", err)
+		return
+	}
+	fmt.Println("RPC timeout configured to :", timeout, "seconds")
+}
+
+// ChangeFeeRates sets the slow, normal and fast rates of a network in Hanas per byte
+func ChangeFeeRates(network string, slow string, normal string, fast string) {
+	if _, ok := NetworkHRPs[network]; !ok {
+		fmt.Println("Error: unknown network, use mainnet or testnet:", network)
+		return
+	}
+	var values [3]uint64
+	for i, input := range []string{slow, normal, fast} {
+		rate, err := strconv.ParseUint(input, 10, 64)
+		if err != nil {
+			fmt.Println("Error: fee rate must be a number of Hanas per byte:", input)
+			return
+		}
+		if rate < MinFeeRate {
+			fmt.Printf("Error: fee rate of %d Hanas per byte is below the minimum of %d\n", rate, MinFeeRate)
+			return
+		}
+		values[i] = rate
+	}
+	if values[0] > values[1] || values[1] > values[2] {
+		fmt.Println("Error: fee rates must go up from slow to normal to fast")
+		return
+	}
+	config, err := LoadConfig()
+	if err != nil {
+		fmt.Println("Error loading config:", err)
+		return
+	}
+	if config.FeeRates == nil {
+		config.FeeRates = map[string]FeeRates{}
+	}
+	config.FeeRates[network] = FeeRates{Slow: values[0], Normal: values[1], Fast: values[2]}
+	err = SaveConfig(config)
+	if err != nil {
+		fmt.Println("Error saving config:", err)
+		return
+	}
+	fmt.Printf("Fee rates of %s configured to : slow %d, normal %d, fast %d Hanas per byte\n", network, values[0], values[1], values[2])
+}
+
+// ChangeFeeTier sets the fee tier of a txn without --fee-tier or --fee-rate
+func ChangeFeeTier(tier string) {
+	tier = strings.ToLower(tier)
+	if _, ok := (FeeRates{}).Rate(tier); !ok {
+		fmt.Println("Error: fee tier must be slow, normal or fast:", tier)
+		return
+	}
+	config, err := LoadConfig()
+	if err != nil {
+		fmt.Println("Error loading config:", err)
+		return
+	}
+	config.FeeTier = tier
+	err = SaveConfig(config)
+	if err != nil {
+		fmt.Println("Error saving config:", err)
+		return
+	}
+	fmt.Println("Fee tier configured to :", tier)
+}
+
+// ChangeAmountUnit sets the unit of an amount typed without one
+func ChangeAmountUnit(input string) {
+	unit, err := amounts.ParseUnit(input)
+	if err != nil {
+		fmt.Println("Error:", err)
+		return
+	}
+	config, err := LoadConfig()
+	if err != nil {
+		fmt.Println("Error loading config:", err)
+		return
+	}
+	config.AmountUnit = unit
+	err = SaveConfig(config)
+	if err != nil {
+		fmt.Println("Error saving config:", err)
+		return
+	}
+	fmt.Println("Amount unit configured to :", unit)
 }
 
 func ChangeWalletPath(walletpath string) {
@@ -0,0 +1,32 @@
+// configTB_test.go
+package tbfunctions
+
+import (
+	"testing"
+
+	"tbwallet/amounts"
+)
+
+func TestDefaultAmountUnit(t *testing.T) {
+	tests := map[string]string{
+		"":      amounts.UnitHanas,
+		"hanas": amounts.UnitHanas,
+		"TBYT":  amounts.UnitTBYT,
+		"tbyt":  amounts.UnitTBYT,
+		"btc":   amounts.UnitHanas,
+	}
+	for configured, want := range tests {
+		if got := (Config{AmountUnit: configured}).DefaultAmountUnit(); got != want {
+			t.Errorf("DefaultAmountUnit(%q) = %s, want %s", configured, got, want)
+		}
+	}
+
+	// 0.001 is read in the configured unit, explicit units win
+	tbyt := Config{AmountUnit: amounts.UnitTBYT}.DefaultAmountUnit()
+	if hanas, err := amounts.Parse("0.001", tbyt); err != nil || hanas.String() != "10000" {
+		t.Errorf("Parse(0.001, TBYT) = %v, %v, want 10000", hanas, err)
+	}
+	if hanas, err := amounts.Parse("5hanas", tbyt); err != nil || hanas.String() != "5" {
+		t.Errorf("Parse(5hanas, TBYT) = %v, %v, want 5", hanas, err)
+	}
+}
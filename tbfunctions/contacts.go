@@ -0,0 +1,301 @@
+// contacts.go
+package tbfunctions
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"tbwallet/keys"
+)
+
+// Contact is an address book entry. Address is stored as lower case 0x hex and
+// a label is unique per network.
+type Contact struct {
+	Label   string `json:"label"`
+	Address string `json:"address"`
+	Network string `json:"network"`
+	Note    string `json:"note,omitempty"`
+}
+
+// contactsFile returns the address book path, next to config.json
+func contactsFile() string {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		log.Fatalf("Unable to get the user's home directory: %v", err)
+	}
+	return filepath.Join(homeDir, ".config", "tbwallet", "contacts.json")
+}
+
+// LoadContacts reads the address book, a missing file is an empty address book
+func LoadContacts() ([]Contact, error) {
+	var contacts []Contact
+	data, err := os.ReadFile(contactsFile())
+	if errors.Is(err, os.ErrNotExist) {
+		return contacts, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(data, &contacts)
+	if err != nil {
+		return nil, fmt.Errorf("invalid contacts file: %w", err)
+	}
+	return contacts, nil
+}
+
+// SaveContacts writes the address book sorted by network and label
+func SaveContacts(contacts []Contact) error {
+	sort.Slice(contacts, func(i, j int) bool {
+		if contacts[i].Network != contacts[j].Network {
+			return contacts[i].Network < contacts[j].Network
+		}
+		return contacts[i].Label < contacts[j].Label
+	})
+	data, err := json.MarshalIndent(contacts, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(contactsFile(), data, 0644)
+}
+
+// ParseContactLabel strips the leading @ and checks that the label only has
+// letters, digits, '-', '_' and '.'
+func ParseContactLabel(input string) (string, error) {
+	label := strings.TrimPrefix(input, "@")
+	if label == "" {
+		return "", errors.New("contact label is empty")
+	}
+	for _, c := range label {
+		isLetter := (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+		isDigit := c >= '0' && c <= '9'
+		if !isLetter && !isDigit && c != '-' && c != '_' && c != '.' {
+			return "", fmt.Errorf("contact label %q may only contain letters, digits, '-', '_' and '.'", label)
+		}
+	}
+	return label, nil
+}
+
+// findContact returns the position of label on network in contacts, or -1
+func findContact(contacts []Contact, label string, network string) int {
+	for i, contact := range contacts {
+		if strings.EqualFold(contact.Label, label) && contact.Network == network {
+			return i
+		}
+	}
+	return -1
+}
+
+// ResolveContact looks up "@label" on the configured network
+func ResolveContact(input string) (Contact, error) {
+	config, err := LoadConfig()
+	if err != nil {
+		return Contact{}, fmt.Errorf("error loading config: %w", err)
+	}
+	return ResolveNetworkContact(input, config.Network)
+}
+
+// ResolveNetworkContact looks up "@label" on network
+func ResolveNetworkContact(input string, network string) (Contact, error) {
+	label, err := ParseContactLabel(input)
+	if err != nil {
+		return Contact{}, err
+	}
+	contacts, err := LoadContacts()
+	if err != nil {
+		return Contact{}, err
+	}
+	if i := findContact(contacts, label, network); i >= 0 {
+		return contacts[i], nil
+	}
+	for _, contact := range contacts {
+		if strings.EqualFold(contact.Label, label) {
+			return Contact{}, fmt.Errorf("contact @%s is on %s but the wallet is on %s", contact.Label, contact.Network, network)
+		}
+	}
+	return Contact{}, fmt.Errorf("no contact @%s, see \"tbwallet contacts list\"", label)
+}
+
+// contactAddress parses a hex or Bech32m address for a contact on network.
+// Hex addresses follow the txn rules: a bad EIP-55 checksum is rejected and an
+// address without checksum needs --allow-lowercase.
+func contactAddress(input string, network string) (string, error) {
+	if addressNetwork, isBech32 := Bech32Network(input); isBech32 {
+		if addressNetwork != network {
+			return "", fmt.Errorf("%s is a %s address but the contact is on %s", input, addressNetwork, network)
+		}
+		return ParseNetworkBech32Address(input, network)
+	}
+	if len(input) != 42 || !strings.HasPrefix(input, "0x") || !IsValidHex(input[2:]) {
+		return "", fmt.Errorf("%s is not a 0x hex or bech32 address", input)
+	}
+	err := keys.VerifyAddressChecksum(input)
+	if err == keys.ErrAddressNoChecksum && !HasFlag("--allow-lowercase") {
+		return "", errors.New("address has no EIP-55 checksum, paste the mixed-case address or add --allow-lowercase")
+	} else if err != nil && err != keys.ErrAddressNoChecksum {
+		return "", err
+	}
+	return strings.ToLower(input), nil
+}
+
+// contactNetwork returns the --network flag or the configured network
+func contactNetwork() (string, error) {
+	if network, isGiven := FlagValue("--network"); isGiven {
+		if _, ok := NetworkHRPs[network]; !ok {
+			return "", fmt.Errorf("unknown network %q, use mainnet or testnet", network)
+		}
+		return network, nil
+	}
+	config, err := LoadConfig()
+	if err != nil {
+		return "", fmt.Errorf("error loading config: %w", err)
+	}
+	return config.Network, nil
+}
+
+// ManageContacts runs the contacts add|rm|list|show subcommands
+func ManageContacts() {
+	args := PositionalArgs("--note", "--network")
+	if len(args) < 3 || HasFlag("-h", "--help") {
+		PrintContactsHelp()
+		return
+	}
+	var err error
+	switch args[2] {
+	case "add":
+		if len(args) != 5 {
+			PrintContactsHelp()
+			return
+		}
+		err = addContact(args[3], args[4])
+	case "rm", "remove":
+		if len(args) != 4 {
+			PrintContactsHelp()
+			return
+		}
+		err = removeContact(args[3])
+	case "list":
+		err = listContacts()
+	case "show":
+		if len(args) != 4 {
+			PrintContactsHelp()
+			return
+		}
+		err = showContact(args[3])
+	default:
+		PrintContactsHelp()
+		return
+	}
+	if err != nil {
+		fmt.Println("Error:", err)
+	}
+}
+
+func addContact(labelInput string, addressInput string) error {
+	label, err := ParseContactLabel(labelInput)
+	if err != nil {
+		return err
+	}
+	network, err := contactNetwork()
+	if err != nil {
+		return err
+	}
+	address, err := contactAddress(addressInput, network)
+	if err != nil {
+		return err
+	}
+	contacts, err := LoadContacts()
+	if err != nil {
+		return err
+	}
+	if findContact(contacts, label, network) >= 0 {
+		return fmt.Errorf("contact @%s already exists on %s, remove it first", label, network)
+	}
+	note, _ := FlagValue("--note")
+	contacts = append(contacts, Contact{Label: label, Address: address, Network: network, Note: note})
+	err = SaveContacts(contacts)
+	if err != nil {
+		return fmt.Errorf("error saving contacts: %w", err)
+	}
+	fmt.Printf("Added @%s (%s) on %s\n", label, DisplayAddress(address), network)
+	return nil
+}
+
+func removeContact(labelInput string) error {
+	label, err := ParseContactLabel(labelInput)
+	if err != nil {
+		return err
+	}
+	network, err := contactNetwork()
+	if err != nil {
+		return err
+	}
+	contacts, err := LoadContacts()
+	if err != nil {
+		return err
+	}
+	i := findContact(contacts, label, network)
+	if i < 0 {
+		return fmt.Errorf("no contact @%s on %s", label, network)
+	}
+	contacts = append(contacts[:i], contacts[i+1:]...)
+	err = SaveContacts(contacts)
+	if err != nil {
+		return fmt.Errorf("error saving contacts: %w", err)
+	}
+	fmt.Printf("Removed @%s from %s\n", label, network)
+	return nil
+}
+
+func listContacts() error {
+	contacts, err := LoadContacts()
+	if err != nil {
+		return err
+	}
+	if len(contacts) == 0 {
+		fmt.Println("No contacts, add one with \"tbwallet contacts add <label> <address>\"")
+		return nil
+	}
+	fmt.Printf("%-20s %-8s %-42s  %s\n", "LABEL", "NETWORK", "ADDRESS", "NOTE")
+	for _, contact := range contacts {
+		fmt.Printf("%-20s %-8s %-42s  %s\n", "@"+contact.Label, contact.Network, DisplayAddress(contact.Address), contact.Note)
+	}
+	return nil
+}
+
+func showContact(labelInput string) error {
+	label, err := ParseContactLabel(labelInput)
+	if err != nil {
+		return err
+	}
+	network, err := contactNetwork()
+	if err != nil {
+		return err
+	}
+	contacts, err := LoadContacts()
+	if err != nil {
+		return err
+	}
+	i := findContact(contacts, label, network)
+	if i < 0 {
+		return fmt.Errorf("no contact @%s on %s", label, network)
+	}
+	contact := contacts[i]
+	bech32Address, err := EncodeNetworkBech32Address(contact.Address, contact.Network)
+	if err != nil {
+		return err
+	}
+	fmt.Println("Label   : @" + contact.Label)
+	fmt.Println("Network : " + contact.Network)
+	fmt.Println("Address : " + DisplayAddress(contact.Address))
+	fmt.Println("Bech32  : " + bech32Address)
+	if contact.Note != "" {
+		fmt.Println("Note    : " + contact.Note)
+	}
+	return nil
+}
@@ -31,10 +31,33 @@ Usage: tbwallet <flags> or <SUBCOMMANDS> <sub-flags>
 
 SUBCOMMANDS:
     create-wallet, create                Create a Tulobyte SegWit Bech32 TB wallet.
+                                           --language <lang>  Recovery phrase language: english, spanish,
+                                           french, italian, japanese, korean, chinese-simplified,
+                                           chinese-traditional, czech (default english). Portuguese
+                                           isn't available yet, its wordlist isn't bundled.
+                                           --path <path>      BIP-32 derivation path (default m/44'/202'/0'/0/0)
+                                           --preset <name>    Path preset: tulobyte, ethereum, ledger-legacy
     recover                              Recover your wallet using a private key or recovery phrase.
     address                              Display your wallet address.
+                                           --account <n> --index <i>  Address at account n and index i of the wallet path
+                                           --new                      Next unused receive address
+                                           --path, --preset           Address at another path of the wallet seed
+                                           --format bech32|hex        Bech32m (tb1... mainnet, ttb1... testnet)
+                                                                      or EIP-55 hex (default)
     pubkey                               Display your wallet's public key.
-    balance                              Check your wallet balance.
+    vanity                               Generate a wallet whose address has a chosen prefix or suffix.
+    export                               Export the wallet as a v3 keystore or an account xpub (--xpub).
+    backup                               Split the wallet seed into SLIP-39 Shamir backup shares.
+    encrypt                              Encrypt a wallet file saved by an older version.
+    migrate                              Fix the stored address of wallets saved by older versions.
+    history                              List the transactions built, signed and broadcast by this wallet.
+    nonce show|reset                     Show the pending nonces of your wallet, or forget them.
+    contacts                             Manage the address book, pay a contact with "txn @label".
+    balance [address]                    Check the confirmed and pending balance of your wallet,
+                                         or of a 0x, bech32 or @contact address.
+                                           --account <n> --index <i>  Wallet address to check
+                                           --network mainnet|testnet  Network to query (default: configured)
+                                           --watch [--interval <s>]   Keep refreshing and print changes
     config                               Manage Tulobyte command-line tool configuration settings.
     txn                                  Calculate transaction size, fees, and perform actual transfers.
 
@@ -46,6 +69,8 @@ FLAGS:
     -h, --help                           Display help options.
     -v, --version                        Display the application version.
     -r, --refresh                        Initiliaze important directory and files 
+    --unit tbyt|hanas                    Print amounts in this unit (default TBYT). It doesn't change
+                                         amounts you type, see "tbwallet config -amount-unit".
 
 SUB-FLAGS:
     -h, --help                           Display help options for subcommands.
@@ -80,27 +105,91 @@ Values:
                                              to win.
     -batch normal                 Update batch to Normal
     -batch hunter                 Update batch to Hunter
+    -rpc-url <network> <url>      Set the node JSON-RPC URL of mainnet or testnet.
+                                  Example usage:
+                                  -  tbwallet config -rpc-url testnet http://127.0.0.1:18545
+    -rpc-url -d                   Display the node URLs and the request timeout.
+    -rpc-timeout <seconds>        Set the node request timeout (default 10).
+    -fee-rates <network> <slow> <normal> <fast>
+                                  Set the fee tier rates of mainnet or testnet in Hanas
+                                  per byte (default mainnet 10 15 25, testnet 10 10 10).
+    -fee-rates -d                 Display the fee tier and the fee rates.
+    -fee-tier slow|normal|fast    Set the fee tier of a txn without --fee-tier (default normal).
+    -amount-unit tbyt|hanas       Set the unit of an amount typed without one (default hanas).
+    -amount-unit -d               Display the amount unit.
 `
 	fmt.Println(helpText)
 }
 
 func PrintTxnHelp() {
 	helpText := `
-Usage: tbwallet txn <RECIPIENT_ADDRESS> <AMOUNT> <DATA>
+Usage: tbwallet txn <RECIPIENT_ADDRESS> <AMOUNT> <DATA> [--account <n>] [--from-index <i>] [--allow-lowercase]
 
 Arguments:
-    RECIPIENT_ADDRESS           The address to which you want to send TBS.
+    RECIPIENT_ADDRESS           The address to which you want to send TBS, as 0x hex or
+                                Bech32m (tb1... on mainnet, ttb1... on testnet), or
+                                @label of a contact (see "tbwallet contacts").
 
-    AMOUNT                      The amount to transfer, specified in Hanabytes.
-                                Note: 1 TBT = 10,000,000 Hanabytes.
+    AMOUNT                      The amount to transfer with its unit, e.g. 1.5TBYT,
+                                0.001TBYT or 1500000hanas. A plain number such as 0.001
+                                is in the unit set with "tbwallet config -amount-unit",
+                                Hanas by default. More decimals than the unit has are
+                                rejected, never rounded.
+                                Note: 1 TBYT = 10,000,000 Hanas.
 
     DATA                        Additional data to include in the transaction for
                                 verification purposes 
                                                     OR
                                 This can be any text, such as a base64-encoded image or a quote.
 
+    --account, --from-index     Send from the address at account n and index i of the
+                                wallet path instead of the default address.
+
+    --allow-lowercase           Accept a recipient address without an EIP-55 checksum
+                                (all lower case), a warning is shown. Mixed-case
+                                addresses are always checked.
+
+    --fee-tier slow|normal|fast Pay the fee rate of the tier, the rates are set per
+                                network with "tbwallet config -fee-rates".
+    --fee-rate <hanas>          Pay this many Hanas per byte instead of a tier, at
+                                least 10.
+    --max-fee <amount>          Abort before signing when the fee is higher. Also
+                                works with txn build and txn sign.
+
     NOTE: The maximum size limit of a transaction is 1MB (1024KB).
 
+Air-gapped signing:
+    tbwallet txn build <RECIPIENT_ADDRESS> <AMOUNT> <DATA> [--out <file>]
+                                Online, also on a watch-only wallet: check the inputs
+                                against the node and write an unsigned transaction
+                                file with the nonce and balance it was built with.
+    tbwallet txn sign <FILE> [--out <file>] [--max-fee <amount>]
+                                Offline, on the wallet that holds the key: review
+                                every field, confirm and write the signed txn.json.
+    tbwallet txn broadcast <FILE>
+                                Online: check the signed file and broadcast it.
+
+    The broadcast outcome and the node response are saved as broadcast.json next
+    to the transaction file. Exit codes of txn and txn broadcast:
+        0  broadcast            3  rejected by the node
+        1  invalid input        4  node already has the transaction
+        2  node unreachable     5  nonce too low or too high
+
+Usage: tbwallet txn estimate <RECIPIENT_ADDRESS> <AMOUNT> <DATA> [--network <net>] [--fee-rate <hanas>]
+                            [--max-fee <amount>]
+
+    Print the size in bytes and the fee of the transaction for each batch type,
+    data attachment option and fee tier, without signing it. The options are no
+    data and DATA as typed, which is what txn sends, and when DATA is the path
+    of a file, the file bytes and the file as base64. Fees above --max-fee are
+    marked with !. The key isn't used.
+
+Usage: tbwallet txn verify <TXN_FILE>
+
+    Check a signed txn.json without the wallet: the fields must hash to h, the
+    signature sg must be from the sender s and the fee f must cover the size.
+    Every check is reported as PASS or FAIL, the exit code is 1 if one fails.
+
 `
 	fmt.Println(helpText)
 }
@@ -136,8 +225,136 @@ Usage: tbwallet recover <flags>
 
 flags:
     -h, --help                       Display help options
-    -m, --mnemonic                   To recover wallet using mnemonic phrase or recovery phrase
+    -m, --mnemonic                   To recover wallet using mnemonic phrase or recovery phrase,
+                                     the phrase language is detected from its words
+        --legacy                     Accept phrases without a BIP-39 checksum made by older versions
+        --path <path>                BIP-32 derivation path (default m/44'/202'/0'/0/0)
+        --preset <name>              Path preset: tulobyte, ethereum (MetaMask), ledger-legacy
     -p, --privatekey                 To recover wallet using private key
+    -k, --keystore <file>            To recover wallet from a Web3 Secret Storage (v3) JSON keystore
+    --xpub <xpub>                    To create a watch-only wallet from an account extended public key,
+                                     --path or --preset selects the account (default m/44'/202'/0'/0/0).
+                                     It shows addresses and balances and builds unsigned transactions
+    --shamir                         To recover wallet from SLIP-39 Shamir backup shares,
+                                     --path and --preset apply as with -m
+`
+	fmt.Println(helpText)
+}
+
+// PrintExportHelp shows the export subcommand usage
+func PrintExportHelp() {
+	helpText := `
+Usage: tbwallet export <flags>
+
+flags:
+    -h, --help                       Display help options
+    -k, --keystore <file>            Write the wallet to a Web3 Secret Storage (v3) JSON keystore
+    --kdf scrypt|pbkdf2              Key derivation function for the keystore (default scrypt)
+    --xpub [--account <n>]           Print the account extended public key for a watch-only wallet
+`
+	fmt.Println(helpText)
+}
+
+// PrintVanityHelp shows the vanity subcommand usage
+func PrintVanityHelp() {
+	helpText := `
+Usage: tbwallet vanity --prefix <hex> --suffix <hex> <flags>
+
+flags:
+    -h, --help                       Display help options
+    --prefix <hex>                   Characters the address must start with, after 0x
+    --suffix <hex>                   Characters the address must end with
+    --case-sensitive                 Match the case of the EIP-55 checksummed address,
+                                     every letter doubles the search time
+    --workers <n>                    Number of worker goroutines (default: all CPUs)
+
+    Every extra character makes the search 16 times longer. The wallet has no
+    recovery phrase, write the private key down.
+`
+	fmt.Println(helpText)
+}
+
+// PrintContactsHelp shows the contacts subcommand usage
+func PrintContactsHelp() {
+	helpText := `
+Usage: tbwallet contacts add|rm|list|show <args> <flags>
+
+subcommands:
+    add <label> <address>            Add a contact, the address may be EIP-55 hex or bech32
+    rm <label>                       Remove a contact
+    list                             List the contacts of all networks
+    show <label>                     Show the hex and bech32 address of a contact
+
+flags:
+    -h, --help                       Display help options
+    --note <text>                    Note stored with the contact (add)
+    --network mainnet|testnet        Network of the contact (default: the configured network)
+    --allow-lowercase                Accept a hex address without EIP-55 checksum (add)
+
+    Labels may contain letters, digits, '-', '_' and '.'. Use "@label" in place
+    of the recipient address of "tbwallet txn".
+
+Example:
+    tbwallet contacts add alice 0x9858EfFD232B4033E47d90003D41EC34EcaEda94 --note "rent"
+    tbwallet txn @alice 100 "march rent"
+`
+	fmt.Println(helpText)
+}
+
+// PrintHistoryHelp shows the history subcommand usage
+func PrintHistoryHelp() {
+	helpText := `
+Usage: tbwallet history <flags>
+
+flags:
+    -h, --help                       Display help options
+    --network mainnet|testnet        Network to list (default: the configured network)
+    --to <address>                   Only transactions to a 0x, bech32 or @contact address
+    --since <when>                   Only transactions from YYYY-MM-DD, an RFC 3339 time,
+                                     or an age such as 36h or 7d
+    --min-amount <amount>            Only transactions of at least this amount
+    --status <status>                Only transactions with the status unsigned, signed,
+                                     broadcast, failed, rejected, duplicate or nonce-conflict
+    --limit <n>                      Transactions per page (default 20)
+    --page <n>                       Page to show, newest transactions first
+    --show <hash>                    Show every field of one transaction, a unique start
+                                     of the hash is enough
+`
+	fmt.Println(helpText)
+}
+
+// PrintNonceHelp shows the nonce subcommand usage
+func PrintNonceHelp() {
+	helpText := `
+Usage: tbwallet nonce show|reset <flags>
+
+subcommands:
+    show                             Show the confirmed nonce from the node, the pending
+                                     transactions of the local ledger, the next free nonce,
+                                     and warn about gaps and reused nonces
+    reset                            Forget the pending transactions of the address, the
+                                     next nonce then comes from the node alone
+
+flags:
+    -h, --help                       Display help options
+    --account <n> --index <i>        Wallet address to use (default: the wallet address)
+    --network mainnet|testnet        Network of the ledger (default: the configured network)
+`
+	fmt.Println(helpText)
+}
+
+// PrintBackupHelp shows the backup subcommand usage
+func PrintBackupHelp() {
+	helpText := `
+Usage: tbwallet backup shamir --threshold <t> --shares <n>
+
+flags:
+    -h, --help                       Display help options
+    --threshold <t>                  Number of shares needed to recover the wallet (2 to n)
+    --shares <n>                     Number of shares to create (at most 16)
+
+Example:
+    tbwallet backup shamir --threshold 3 --shares 5
 `
 	fmt.Println(helpText)
 }
@@ -0,0 +1,312 @@
+// keystore.go
+package tbfunctions
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/crypto/scrypt"
+	"golang.org/x/term"
+)
+
+// Wallet file format version written by SaveWallet
+const WalletFileVersion = 1
+
+// Scrypt parameters used to derive the wallet encryption key
+const (
+	ScryptN     = 1 << 18
+	ScryptR     = 8
+	ScryptP     = 1
+	ScryptDKLen = 32
+)
+
+// PasswordFileEnv names a file holding the wallet password, used instead of the prompt
+const PasswordFileEnv = "TBWALLET_PASSWORD_FILE"
+
+// ErrWrongPassword is returned when the wallet cannot be opened with the given password
+var ErrWrongPassword = errors.New("could not decrypt wallet, wrong password")
+
+// ErrWatchOnly is returned when a private key or the seed is needed from a watch-only wallet
+var ErrWatchOnly = errors.New("this is a watch-only wallet, it has no private keys and can't sign, sign on the wallet that holds the seed")
+
+// WalletFile is the encrypted wallet stored at config.WalletPath. Crypto holds
+// the key of the default address, Seed is only present for wallets created or
+// recovered from a recovery phrase. Path is the derivation path of the default
+// address, empty for wallets saved before paths were stored. Watch-only wallets
+// have neither Crypto nor Seed, only the account xpubs.
+type WalletFile struct {
+	Version   int                       `json:"version"`
+	Address   string                    `json:"address"`
+	PublicKey string                    `json:"publickey"`
+	WatchOnly bool                      `json:"watchonly,omitempty"`
+	Crypto    *WalletCrypto             `json:"crypto,omitempty"`
+	Seed      *WalletCrypto             `json:"seed,omitempty"`
+	Path      string                    `json:"path,omitempty"`
+	Accounts  map[string]*WalletAccount `json:"accounts,omitempty"`
+}
+
+// WalletAccount caches the account level extended public key so receive
+// addresses can be derived without the password, and the next unused index
+type WalletAccount struct {
+	XPub   string `json:"xpub"`
+	Cursor uint32 `json:"cursor"`
+}
+
+// WalletCrypto holds a secret sealed with AES-256-GCM under a scrypt derived key
+type WalletCrypto struct {
+	Cipher     string       `json:"cipher"`
+	CipherText string       `json:"ciphertext"`
+	Nonce      string       `json:"nonce"`
+	KDF        string       `json:"kdf"`
+	KDFParams  ScryptParams `json:"kdfparams"`
+}
+
+// ScryptParams are the key derivation parameters stored with the ciphertext
+type ScryptParams struct {
+	N     int    `json:"n"`
+	R     int    `json:"r"`
+	P     int    `json:"p"`
+	DKLen int    `json:"dklen"`
+	Salt  string `json:"salt"`
+}
+
+// EncryptSecret seals the secret with a key derived from the password
+func EncryptSecret(secret []byte, password []byte) (WalletCrypto, error) {
+	salt := make([]byte, 32)
+	if _, err := rand.Read(salt); err != nil {
+		return WalletCrypto{}, fmt.Errorf("failed to generate salt: %w", err)
+	}
+	params := ScryptParams{
+		N:     ScryptN,
+		R:     ScryptR,
+		P:     ScryptP,
+		DKLen: ScryptDKLen,
+		Salt:  hex.EncodeToString(salt),
+	}
+	key, err := scrypt.Key(password, salt, params.N, params.R, params.P, params.DKLen)
+	if err != nil {
+		return WalletCrypto{}, fmt.Errorf("failed to derive key: %w", err)
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return WalletCrypto{}, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return WalletCrypto{}, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return WalletCrypto{}, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	cipherText := gcm.Seal(nil, nonce, secret, nil)
+
+	return WalletCrypto{
+		Cipher:     "aes-256-gcm",
+		CipherText: hex.EncodeToString(cipherText),
+		Nonce:      hex.EncodeToString(nonce),
+		KDF:        "scrypt",
+		KDFParams:  params,
+	}, nil
+}
+
+// DecryptSecret opens a secret sealed by EncryptSecret
+func DecryptSecret(c WalletCrypto, password []byte) ([]byte, error) {
+	if c.Cipher != "aes-256-gcm" {
+		return nil, fmt.Errorf("unsupported cipher: %s", c.Cipher)
+	}
+	if c.KDF != "scrypt" {
+		return nil, fmt.Errorf("unsupported kdf: %s", c.KDF)
+	}
+	salt, err := hex.DecodeString(c.KDFParams.Salt)
+	if err != nil {
+		return nil, fmt.Errorf("invalid salt: %w", err)
+	}
+	nonce, err := hex.DecodeString(c.Nonce)
+	if err != nil {
+		return nil, fmt.Errorf("invalid nonce: %w", err)
+	}
+	cipherText, err := hex.DecodeString(c.CipherText)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ciphertext: %w", err)
+	}
+	key, err := scrypt.Key(password, salt, c.KDFParams.N, c.KDFParams.R, c.KDFParams.P, c.KDFParams.DKLen)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive key: %w", err)
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	if len(nonce) != gcm.NonceSize() {
+		return nil, fmt.Errorf("invalid nonce length: %d", len(nonce))
+	}
+	secret, err := gcm.Open(nil, nonce, cipherText, nil)
+	if err != nil {
+		return nil, ErrWrongPassword
+	}
+	return secret, nil
+}
+
+// SaveWallet writes the wallet file with owner only permissions
+func SaveWallet(filename string, wallet WalletFile) error {
+	err := os.MkdirAll(filepath.Dir(filename), 0755)
+	if err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(&wallet, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal wallet: %w", err)
+	}
+
+	err = os.WriteFile(filename, data, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to write wallet file: %w", err)
+	}
+	return nil
+}
+
+// LoadWallet reads the wallet file. Wallets saved before encryption was
+// introduced hold a bare private key hex, these are reported as legacy and
+// the raw key is returned in place of the wallet.
+func LoadWallet(filename string) (WalletFile, []byte, error) {
+	var wallet WalletFile
+
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return wallet, nil, fmt.Errorf("failed to read file: %w", err)
+	}
+
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] != '{' {
+		legacyKey, err := hex.DecodeString(string(data))
+		if err != nil {
+			return wallet, nil, fmt.Errorf("failed to decode hex string: %w", err)
+		}
+		return wallet, legacyKey, nil
+	}
+
+	err = json.Unmarshal(data, &wallet)
+	if err != nil {
+		return wallet, nil, fmt.Errorf("failed to parse wallet file: %w", err)
+	}
+	if wallet.Version != WalletFileVersion {
+		return wallet, nil, fmt.Errorf("unsupported wallet version: %d", wallet.Version)
+	}
+	return wallet, nil, nil
+}
+
+// ReadPassword reads the wallet password from the file named by
+// TBWALLET_PASSWORD_FILE, or prompts for it without echo
+func ReadPassword(prompt string) ([]byte, error) {
+	if passwordFile := os.Getenv(PasswordFileEnv); passwordFile != "" {
+		info, err := os.Stat(passwordFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read password file: %w", err)
+		}
+		if info.Mode().Perm()&0077 != 0 {
+			return nil, fmt.Errorf("password file %s must not be readable by group or others", passwordFile)
+		}
+		data, err := os.ReadFile(passwordFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read password file: %w", err)
+		}
+		return []byte(strings.TrimRight(string(data), "\r\n")), nil
+	}
+
+	fmt.Print(prompt)
+	password, err := term.ReadPassword(int(os.Stdin.Fd()))
+	fmt.Println()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read password: %w", err)
+	}
+	return password, nil
+}
+
+// ReadNewPassword asks for a new wallet password twice and checks both match
+func ReadNewPassword() ([]byte, error) {
+	password, err := ReadPassword("Enter new wallet password: ")
+	if err != nil {
+		return nil, err
+	}
+	if len(password) == 0 {
+		return nil, errors.New("wallet password can't be empty")
+	}
+	// A password file has nothing to confirm
+	if os.Getenv(PasswordFileEnv) != "" {
+		return password, nil
+	}
+	confirm, err := ReadPassword("Confirm wallet password: ")
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(password, confirm) {
+		return nil, errors.New("passwords do not match")
+	}
+	return password, nil
+}
+
+// UnlockSeed prompts for the password and decrypts the wallet seed
+func UnlockSeed(wallet WalletFile) ([]byte, error) {
+	if wallet.WatchOnly {
+		return nil, ErrWatchOnly
+	}
+	if wallet.Seed == nil {
+		return nil, errors.New("wallet has no seed, recover it with a recovery phrase to derive more addresses")
+	}
+	password, err := ReadPassword("Enter wallet password: ")
+	if err != nil {
+		return nil, err
+	}
+	return DecryptSecret(*wallet.Seed, password)
+}
+
+// UnlockPrivateKey prompts for the password and decrypts the wallet private key
+func UnlockPrivateKey(wallet WalletFile) ([]byte, error) {
+	if wallet.WatchOnly || wallet.Crypto == nil {
+		return nil, ErrWatchOnly
+	}
+	password, err := ReadPassword("Enter wallet password: ")
+	if err != nil {
+		return nil, err
+	}
+	return DecryptSecret(*wallet.Crypto, password)
+}
+
+// EncryptLegacyWallet replaces a plaintext wallet file with an encrypted one
+func EncryptLegacyWallet() {
+	config, err := LoadConfig()
+	if err != nil {
+		fmt.Println("Error loading config:", err)
+		return
+	}
+	_, legacyKey, err := LoadWallet(config.WalletPath)
+	if err != nil {
+		fmt.Println("Can't read wallet file:", err)
+		return
+	}
+	if legacyKey == nil {
+		fmt.Println("Wallet is already encrypted")
+		return
+	}
+	err = SavePrivateKey(config.WalletPath, hex.EncodeToString(legacyKey))
+	if err != nil {
+		fmt.Println("Error saving wallet:", err)
+	}
+}
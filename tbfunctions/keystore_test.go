@@ -0,0 +1,158 @@
+// keystore_test.go
+package tbfunctions
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestReadNewPasswordFile(t *testing.T) {
+	passwordFile := filepath.Join(t.TempDir(), "password")
+	t.Setenv(PasswordFileEnv, passwordFile)
+
+	for _, content := range []string{"", "\n", "\r\n"} {
+		if err := os.WriteFile(passwordFile, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+		if password, err := ReadNewPassword(); err == nil {
+			t.Errorf("ReadNewPassword(%q) = %q, want an error", content, password)
+		}
+	}
+
+	if err := os.WriteFile(passwordFile, []byte("hunter2\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	password, err := ReadNewPassword()
+	if err != nil || string(password) != "hunter2" {
+		t.Errorf("ReadNewPassword = %q, %v, want hunter2", password, err)
+	}
+}
+
+// flipByte returns the hex string with the bits of one byte flipped, index counts from the end when negative
+func flipByte(t *testing.T, hexString string, index int) string {
+	t.Helper()
+	data, err := hex.DecodeString(hexString)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if index < 0 {
+		index += len(data)
+	}
+	data[index] ^= 0xff
+	return hex.EncodeToString(data)
+}
+
+func TestEncryptDecryptSecret(t *testing.T) {
+	secret := []byte("0123456789abcdef0123456789abcdef")
+	sealed, err := EncryptSecret(secret, []byte("hunter2"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sealed.Cipher != "aes-256-gcm" || sealed.KDF != "scrypt" || sealed.KDFParams.N != ScryptN {
+		t.Errorf("EncryptSecret = %+v", sealed)
+	}
+	if strings.Contains(sealed.CipherText, hex.EncodeToString(secret)) {
+		t.Error("ciphertext holds the secret")
+	}
+	opened, err := DecryptSecret(sealed, []byte("hunter2"))
+	if err != nil || !bytes.Equal(opened, secret) {
+		t.Errorf("DecryptSecret = %x, %v, want %x", opened, err, secret)
+	}
+
+	if _, err := DecryptSecret(sealed, []byte("hunter3")); !errors.Is(err, ErrWrongPassword) {
+		t.Errorf("DecryptSecret(wrong password) error = %v, want ErrWrongPassword", err)
+	}
+
+	// GCM authenticates the ciphertext, its tag and the nonce
+	tampered := map[string]WalletCrypto{}
+	body := sealed
+	body.CipherText = flipByte(t, sealed.CipherText, 0)
+	tampered["ciphertext"] = body
+	tag := sealed
+	tag.CipherText = flipByte(t, sealed.CipherText, -1)
+	tampered["tag"] = tag
+	nonce := sealed
+	nonce.Nonce = flipByte(t, sealed.Nonce, 0)
+	tampered["nonce"] = nonce
+	salt := sealed
+	salt.KDFParams.Salt = flipByte(t, sealed.KDFParams.Salt, 0)
+	tampered["salt"] = salt
+	for name, c := range tampered {
+		if secret, err := DecryptSecret(c, []byte("hunter2")); !errors.Is(err, ErrWrongPassword) {
+			t.Errorf("DecryptSecret(tampered %s) = %x, %v, want ErrWrongPassword", name, secret, err)
+		}
+	}
+
+	// Unknown algorithms and malformed fields fail before the key is derived
+	invalid := map[string]func(c *WalletCrypto){
+		"cipher":     func(c *WalletCrypto) { c.Cipher = "aes-128-ctr" },
+		"kdf":        func(c *WalletCrypto) { c.KDF = "pbkdf2" },
+		"salt hex":   func(c *WalletCrypto) { c.KDFParams.Salt = "zz" },
+		"nonce hex":  func(c *WalletCrypto) { c.Nonce = "zz" },
+		"ciphertext": func(c *WalletCrypto) { c.CipherText = "zz" },
+	}
+	for name, change := range invalid {
+		c := sealed
+		change(&c)
+		if _, err := DecryptSecret(c, []byte("hunter2")); err == nil || errors.Is(err, ErrWrongPassword) {
+			t.Errorf("DecryptSecret(invalid %s) error = %v", name, err)
+		}
+	}
+}
+
+func TestLoadWallet(t *testing.T) {
+	folder := t.TempDir()
+	sealed, err := EncryptSecret([]byte{0x01, 0x02}, []byte("hunter2"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	wallet := WalletFile{
+		Version:   WalletFileVersion,
+		Address:   "0x6fac4d18c912343bf86fa7049364dd4e424ab9c0",
+		PublicKey: "04ab",
+		Crypto:    &sealed,
+	}
+	filename := filepath.Join(folder, "wallet", "wallet.json")
+	if err := SaveWallet(filename, wallet); err != nil {
+		t.Fatal(err)
+	}
+	if info, err := os.Stat(filename); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("wallet file mode = %v, %v, want 0600", info.Mode().Perm(), err)
+	}
+	loaded, legacyKey, err := LoadWallet(filename)
+	if err != nil || legacyKey != nil {
+		t.Fatalf("LoadWallet = %x, %v", legacyKey, err)
+	}
+	if loaded.Address != wallet.Address || loaded.Crypto == nil || *loaded.Crypto != sealed {
+		t.Errorf("LoadWallet = %+v, want %+v", loaded, wallet)
+	}
+
+	// Wallets written by another version are refused
+	wallet.Version = WalletFileVersion + 1
+	if err := SaveWallet(filename, wallet); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := LoadWallet(filename); err == nil || !strings.Contains(err.Error(), "unsupported wallet version") {
+		t.Errorf("LoadWallet(version %d) error = %v", wallet.Version, err)
+	}
+
+	// A legacy wallet is a bare private key hex
+	legacy := filepath.Join(folder, "legacy.json")
+	if err := os.WriteFile(legacy, []byte("0a0b0c\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, legacyKey, err := LoadWallet(legacy); err != nil || !bytes.Equal(legacyKey, []byte{0x0a, 0x0b, 0x0c}) {
+		t.Errorf("LoadWallet(legacy) = %x, %v, want 0a0b0c", legacyKey, err)
+	}
+	if err := os.WriteFile(legacy, []byte("not a key"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := LoadWallet(legacy); err == nil {
+		t.Error("LoadWallet(invalid legacy) succeeded")
+	}
+}
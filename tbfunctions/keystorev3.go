@@ -0,0 +1,268 @@
+// keystorev3.go
+package tbfunctions
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"tbwallet/keys"
+
+	"golang.org/x/crypto/pbkdf2"
+	"golang.org/x/crypto/scrypt"
+	"golang.org/x/crypto/sha3"
+)
+
+// PBKDF2 iteration count used when exporting with the pbkdf2 kdf
+const PBKDF2Iterations = 262144
+
+// ErrKeystoreMAC is returned when a v3 keystore MAC does not match, usually a wrong password
+var ErrKeystoreMAC = errors.New("could not decrypt keystore, wrong password")
+
+// ErrKeystoreAddress is returned when the decrypted key doesn't belong to the keystore address
+var ErrKeystoreAddress = errors.New("keystore key does not match its address")
+
+// KeystoreV3 is the Web3 Secret Storage (version 3) JSON layout
+type KeystoreV3 struct {
+	Address string           `json:"address"`
+	Crypto  KeystoreV3Crypto `json:"crypto"`
+	ID      string           `json:"id"`
+	Version int              `json:"version"`
+}
+
+// KeystoreV3Crypto is the crypto section of a v3 keystore
+type KeystoreV3Crypto struct {
+	Cipher       string                 `json:"cipher"`
+	CipherText   string                 `json:"ciphertext"`
+	CipherParams KeystoreV3CipherParams `json:"cipherparams"`
+	KDF          string                 `json:"kdf"`
+	KDFParams    map[string]interface{} `json:"kdfparams"`
+	MAC          string                 `json:"mac"`
+}
+
+// KeystoreV3CipherParams holds the aes-128-ctr initialisation vector
+type KeystoreV3CipherParams struct {
+	IV string `json:"iv"`
+}
+
+// EncryptKeystoreV3 seals a private key into a v3 keystore using the scrypt or pbkdf2 kdf
+func EncryptKeystoreV3(privateKey []byte, address string, password []byte, kdf string) (KeystoreV3, error) {
+	salt := make([]byte, 32)
+	if _, err := rand.Read(salt); err != nil {
+		return KeystoreV3{}, fmt.Errorf("failed to generate salt: %w", err)
+	}
+
+	var derivedKey []byte
+	var kdfParams map[string]interface{}
+	switch kdf {
+	case "scrypt":
+		key, err := scrypt.Key(password, salt, ScryptN, ScryptR, ScryptP, 32)
+		if err != nil {
+			return KeystoreV3{}, fmt.Errorf("failed to derive key: %w", err)
+		}
+		derivedKey = key
+		kdfParams = map[string]interface{}{
+			"n":     ScryptN,
+			"r":     ScryptR,
+			"p":     ScryptP,
+			"dklen": 32,
+			"salt":  hex.EncodeToString(salt),
+		}
+	case "pbkdf2":
+		derivedKey = pbkdf2.Key(password, salt, PBKDF2Iterations, 32, sha256.New)
+		kdfParams = map[string]interface{}{
+			"c":     PBKDF2Iterations,
+			"prf":   "hmac-sha256",
+			"dklen": 32,
+			"salt":  hex.EncodeToString(salt),
+		}
+	default:
+		return KeystoreV3{}, fmt.Errorf("unsupported kdf: %s", kdf)
+	}
+
+	iv := make([]byte, aes.BlockSize)
+	if _, err := rand.Read(iv); err != nil {
+		return KeystoreV3{}, fmt.Errorf("failed to generate iv: %w", err)
+	}
+	cipherText, err := aesCTR(derivedKey[:16], iv, privateKey)
+	if err != nil {
+		return KeystoreV3{}, err
+	}
+
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return KeystoreV3{}, fmt.Errorf("failed to generate id: %w", err)
+	}
+
+	return KeystoreV3{
+		Address: strings.TrimPrefix(strings.ToLower(address), "0x"),
+		Crypto: KeystoreV3Crypto{
+			Cipher:       "aes-128-ctr",
+			CipherText:   hex.EncodeToString(cipherText),
+			CipherParams: KeystoreV3CipherParams{IV: hex.EncodeToString(iv)},
+			KDF:          kdf,
+			KDFParams:    kdfParams,
+			MAC:          hex.EncodeToString(keystoreMAC(derivedKey, cipherText)),
+		},
+		ID:      formatUUID(id),
+		Version: 3,
+	}, nil
+}
+
+// DecryptKeystoreV3 verifies the MAC and returns the private key held in a v3
+// keystore. When the keystore has an address, the key must derive it.
+func DecryptKeystoreV3(keystore KeystoreV3, password []byte) ([]byte, error) {
+	if keystore.Version != 3 {
+		return nil, fmt.Errorf("unsupported keystore version: %d", keystore.Version)
+	}
+	if keystore.Crypto.Cipher != "aes-128-ctr" {
+		return nil, fmt.Errorf("unsupported cipher: %s", keystore.Crypto.Cipher)
+	}
+	cipherText, err := hex.DecodeString(keystore.Crypto.CipherText)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ciphertext: %w", err)
+	}
+	iv, err := hex.DecodeString(keystore.Crypto.CipherParams.IV)
+	if err != nil {
+		return nil, fmt.Errorf("invalid iv: %w", err)
+	}
+	mac, err := hex.DecodeString(keystore.Crypto.MAC)
+	if err != nil {
+		return nil, fmt.Errorf("invalid mac: %w", err)
+	}
+
+	params := keystore.Crypto.KDFParams
+	salt, err := hex.DecodeString(paramString(params, "salt"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid salt: %w", err)
+	}
+	dkLen := paramInt(params, "dklen")
+	if dkLen < 32 {
+		return nil, fmt.Errorf("invalid dklen: %d", dkLen)
+	}
+
+	var derivedKey []byte
+	switch keystore.Crypto.KDF {
+	case "scrypt":
+		derivedKey, err = scrypt.Key(password, salt, paramInt(params, "n"), paramInt(params, "r"), paramInt(params, "p"), dkLen)
+		if err != nil {
+			return nil, fmt.Errorf("failed to derive key: %w", err)
+		}
+	case "pbkdf2":
+		if prf := paramString(params, "prf"); prf != "hmac-sha256" {
+			return nil, fmt.Errorf("unsupported pbkdf2 prf: %s", prf)
+		}
+		iterations := paramInt(params, "c")
+		if iterations <= 0 {
+			return nil, fmt.Errorf("invalid pbkdf2 iteration count: %d", iterations)
+		}
+		derivedKey = pbkdf2.Key(password, salt, iterations, dkLen, sha256.New)
+	default:
+		return nil, fmt.Errorf("unsupported kdf: %s", keystore.Crypto.KDF)
+	}
+
+	if subtle.ConstantTimeCompare(keystoreMAC(derivedKey, cipherText), mac) != 1 {
+		return nil, ErrKeystoreMAC
+	}
+	privateKey, err := aesCTR(derivedKey[:16], iv, cipherText)
+	if err != nil {
+		return nil, err
+	}
+	if keystore.Address == "" {
+		return privateKey, nil
+	}
+	ecdsaKey, err := keys.PrivateKeyFromBytes(privateKey)
+	if err != nil {
+		return nil, err
+	}
+	address := keys.Address(&ecdsaKey.PublicKey)
+	if !strings.EqualFold(strings.TrimPrefix(address, "0x"), strings.TrimPrefix(keystore.Address, "0x")) {
+		return nil, fmt.Errorf("%w: keystore has %s, key derives %s", ErrKeystoreAddress, keystore.Address, address)
+	}
+	return privateKey, nil
+}
+
+// ReadKeystoreV3 parses a v3 keystore JSON file
+func ReadKeystoreV3(filename string) (KeystoreV3, error) {
+	var keystore KeystoreV3
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return keystore, fmt.Errorf("failed to read keystore: %w", err)
+	}
+	err = json.Unmarshal(data, &keystore)
+	if err != nil {
+		return keystore, fmt.Errorf("failed to parse keystore: %w", err)
+	}
+	return keystore, nil
+}
+
+// WriteKeystoreV3 writes a v3 keystore JSON file with owner only permissions
+func WriteKeystoreV3(filename string, keystore KeystoreV3) error {
+	err := os.MkdirAll(filepath.Dir(filename), 0755)
+	if err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+	data, err := json.Marshal(&keystore)
+	if err != nil {
+		return fmt.Errorf("failed to marshal keystore: %w", err)
+	}
+	err = os.WriteFile(filename, data, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to write keystore: %w", err)
+	}
+	return nil
+}
+
+func aesCTR(key []byte, iv []byte, in []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(iv) != aes.BlockSize {
+		return nil, fmt.Errorf("invalid iv length: %d", len(iv))
+	}
+	out := make([]byte, len(in))
+	cipher.NewCTR(block, iv).XORKeyStream(out, in)
+	return out, nil
+}
+
+// keystoreMAC is keccak256(derivedKey[16:32] ++ ciphertext)
+func keystoreMAC(derivedKey []byte, cipherText []byte) []byte {
+	hash := sha3.NewLegacyKeccak256()
+	hash.Write(derivedKey[16:32])
+	hash.Write(cipherText)
+	return hash.Sum(nil)
+}
+
+// formatUUID formats 16 random bytes as a version 4 UUID
+func formatUUID(b []byte) string {
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
+
+func paramInt(params map[string]interface{}, name string) int {
+	if value, ok := params[name].(float64); ok {
+		return int(value)
+	}
+	if value, ok := params[name].(int); ok {
+		return value
+	}
+	return 0
+}
+
+func paramString(params map[string]interface{}, name string) string {
+	if value, ok := params[name].(string); ok {
+		return value
+	}
+	return ""
+}
@@ -0,0 +1,78 @@
+// keystorev3_test.go
+package tbfunctions
+
+import (
+	"encoding/hex"
+	"errors"
+	"testing"
+)
+
+// The pbkdf2 test vector of the Web3 Secret Storage definition
+const (
+	keystoreVectorPassword   = "testpassword"
+	keystoreVectorPrivateKey = "7a28b5ba57c53603b0b07b56bba752f7784bf506fa95edc395f5cf6c7514fe9d"
+	keystoreVectorAddress    = "008aeeda4d805471df9b2a5b0f38a0c3bcba786b"
+)
+
+func keystoreVector() KeystoreV3 {
+	return KeystoreV3{
+		Address: keystoreVectorAddress,
+		Crypto: KeystoreV3Crypto{
+			Cipher:       "aes-128-ctr",
+			CipherText:   "5318b4d5bcd28de64ee5559e671353e16f075ecae9f99c7a79a38af5f869aa46",
+			CipherParams: KeystoreV3CipherParams{IV: "6087dab2f9fdbbfaddc31a909735c1e6"},
+			KDF:          "pbkdf2",
+			KDFParams: map[string]interface{}{
+				"c":     float64(262144),
+				"dklen": float64(32),
+				"prf":   "hmac-sha256",
+				"salt":  "ae3cd4e7013836a3df6bd7241b12db061dbe2c6785853cce422d148a624ce0bd",
+			},
+			MAC: "517ead924a9d0dc3124507e3393d175ce3ff7c1e96529c6c555ce9e51205e9b2",
+		},
+		ID:      "3198bc9c-6672-5ab3-d995-4942343ae5b6",
+		Version: 3,
+	}
+}
+
+func TestDecryptKeystoreV3(t *testing.T) {
+	keystore := keystoreVector()
+	privateKey, err := DecryptKeystoreV3(keystore, []byte(keystoreVectorPassword))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := hex.EncodeToString(privateKey); got != keystoreVectorPrivateKey {
+		t.Errorf("private key = %s, want %s", got, keystoreVectorPrivateKey)
+	}
+
+	if _, err := DecryptKeystoreV3(keystore, []byte("wrongpassword")); !errors.Is(err, ErrKeystoreMAC) {
+		t.Errorf("wrong password: error = %v, want ErrKeystoreMAC", err)
+	}
+
+	// The address may carry 0x and a checksum, or be left out
+	for _, address := range []string{"0x" + keystoreVectorAddress, "0x008AeEda4D805471dF9b2A5B0f38A0C3bCBA786b", ""} {
+		keystore.Address = address
+		if _, err := DecryptKeystoreV3(keystore, []byte(keystoreVectorPassword)); err != nil {
+			t.Errorf("address %q: %v", address, err)
+		}
+	}
+	keystore.Address = "6fac4d18c912343bf86fa7049364dd4e424ab9c0"
+	if _, err := DecryptKeystoreV3(keystore, []byte(keystoreVectorPassword)); !errors.Is(err, ErrKeystoreAddress) {
+		t.Errorf("other address: error = %v, want ErrKeystoreAddress", err)
+	}
+}
+
+func TestKeystoreV3RoundTrip(t *testing.T) {
+	privateKey, _ := hex.DecodeString(keystoreVectorPrivateKey)
+	keystore, err := EncryptKeystoreV3(privateKey, "0x"+keystoreVectorAddress, []byte("secret"), "pbkdf2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if keystore.Address != keystoreVectorAddress {
+		t.Errorf("Address = %s, want %s", keystore.Address, keystoreVectorAddress)
+	}
+	decrypted, err := DecryptKeystoreV3(keystore, []byte("secret"))
+	if err != nil || hex.EncodeToString(decrypted) != keystoreVectorPrivateKey {
+		t.Errorf("DecryptKeystoreV3 = %x, %v", decrypted, err)
+	}
+}
@@ -0,0 +1,40 @@
+// migrate.go
+package tbfunctions
+
+import (
+	"fmt"
+
+	"tbwallet/keys"
+)
+
+// Only warn once per run, several wallet loads can happen in one command
+var migrationWarned bool
+
+// AddressNeedsMigration reports whether the wallet was saved by a version that
+// hashed the public key without padding its coordinates to 32 bytes. Such a
+// stored public key is shorter than 64 bytes and the stored address is not the
+// address the key signs for.
+func AddressNeedsMigration(wallet WalletFile) bool {
+	return wallet.PublicKey != "" && len(wallet.PublicKey) != 2*keys.PublicKeySize
+}
+
+// CheckAddressMigration warns when the stored wallet address will change
+func CheckAddressMigration(wallet WalletFile) {
+	if AddressNeedsMigration(wallet) {
+		printMigrationWarning(wallet.Address)
+	}
+}
+
+func printMigrationWarning(oldAddress string) {
+	if migrationWarned {
+		return
+	}
+	migrationWarned = true
+	fmt.Println(`
++----------------------------------------------------------------+
+| Warning: Wallet address was computed by an older version from  |
+|          an unpadded public key and is not the address of your |
+|          key. Run "tbwallet migrate" to update it.             |
++----------------------------------------------------------------+`)
+	fmt.Println("  Old address:", DisplayAddress(oldAddress))
+}
@@ -4,8 +4,10 @@ package tbfunctions
 import (
 	"fmt"
 	"strings"
+	"unicode"
 
 	eastasianwidth "github.com/moznion/go-unicode-east-asian-width"
+	"golang.org/x/text/unicode/norm"
 )
 
 // PrintWallet prints the details of the wallet including the mnemonic, private key, public key, and Bech32 address.
@@ -21,11 +23,15 @@ func PrintWallet(mnemonic string, privateKeyHex string, publicKeyHex string, add
 	fmt.Fprintf(&formattedInfo, "|  %-90s    |\n", " ")
 	fmt.Fprintf(&formattedInfo, "|  %-90s    |\n", "                                 WALLET "+purpose+" SUCCESSFULLY")
 	fmt.Fprintf(&formattedInfo, "|  %-90s    |\n", " ")
-	if purpose != "RECOVERED" {
+	if purpose != "RECOVERED" && mnemonic == "" {
+		// Wallets made from a bare key, such as vanity wallets, only have the private key as backup
+		printWrappedLine(&formattedInfo, "| ", "NOTE: THIS WALLET HAS NO RECOVERY PHRASE, WRITE THE PRIVATE KEY DOWN IN AN OFFLINE PLACE", boxWidth)
+		fmt.Fprintf(&formattedInfo, "|  %-90s    |\n", " ")
+	} else if purpose != "RECOVERED" {
 		printWrappedLine(&formattedInfo, "| ", "         DON'T COPY/PASTE RECOVERY PHRASE, WRITE OR SAVE IT IN AN OFFLINE PLACE", boxWidth)
 		fmt.Fprintf(&formattedInfo, "|  %-90s    |\n", " ")
 		fmt.Fprintf(&formattedInfo, "|  %-90s    |\n", "Recovery Phrase:")
-		printWrappedLine(&formattedInfo, "| ", mnemonic, boxWidth)
+		printWrappedLine(&formattedInfo, "| ", norm.NFC.String(mnemonic), boxWidth)
 		fmt.Fprintf(&formattedInfo, "|  %-90s    |\n", " ")
 
 	} else {
@@ -37,7 +43,7 @@ func PrintWallet(mnemonic string, privateKeyHex string, publicKeyHex string, add
 	fmt.Fprintf(&formattedInfo, "|  %-90s    |\n", " ")
 	printWrappedLine(&formattedInfo, "| ", "Public Key (hex): "+publicKeyHex, boxWidth)
 	fmt.Fprintf(&formattedInfo, "|  %-90s    |\n", " ")
-	printWrappedLine(&formattedInfo, "| ", "Wallet Address: "+address, boxWidth)
+	printWrappedLine(&formattedInfo, "| ", "Wallet Address: "+DisplayAddress(address), boxWidth)
 	fmt.Fprintf(&formattedInfo, "|  %-90s    |\n", " ")
 
 	fmt.Fprintln(&formattedInfo, border)
@@ -46,6 +52,38 @@ func PrintWallet(mnemonic string, privateKeyHex string, publicKeyHex string, add
 	fmt.Println(formattedInfo.String())
 }
 
+// PrintShare prints one SLIP-39 backup share inside a box
+func PrintShare(number int, total int, share string) {
+	boxWidth := 94
+	border := "+" + strings.Repeat("-", boxWidth+2) + "+"
+
+	var formattedInfo strings.Builder
+	fmt.Fprintln(&formattedInfo, border)
+	fmt.Fprintf(&formattedInfo, "|  %-90s    |\n", fmt.Sprintf("Share %d of %d", number, total))
+	fmt.Fprintf(&formattedInfo, "|  %-90s    |\n", " ")
+	printWrappedWords(&formattedInfo, "| ", share, boxWidth)
+	fmt.Fprintln(&formattedInfo, border)
+	fmt.Println(formattedInfo.String())
+}
+
+// printWrappedWords wraps text at word boundaries so no word is split across lines
+func printWrappedWords(writer *strings.Builder, prefix string, text string, maxWidth int) {
+	var line string
+	for _, word := range strings.Fields(text) {
+		if line != "" && lineWidth([]rune(line+" "+word)) > maxWidth-7 {
+			fmt.Fprintf(writer, "%s       |\n", padToWidth(prefix+" "+line, 90))
+			line = ""
+		}
+		if line != "" {
+			line += " "
+		}
+		line += word
+	}
+	if line != "" {
+		fmt.Fprintf(writer, "%s       |\n", padToWidth(prefix+" "+line, 90))
+	}
+}
+
 // printWrappedLine takes care of wrapping the text to fit within the box
 func printWrappedLine(writer *strings.Builder, prefix string, text string, maxWidth int) {
 	// Wrap the text to fit within maxWidth
@@ -53,13 +91,22 @@ func printWrappedLine(writer *strings.Builder, prefix string, text string, maxWi
 	for i, line := range words {
 		if i == len(words)-1 {
 			// For the last line, do not add an extra space before the "|"
-			fmt.Fprintf(writer, "%-90s       |\n", prefix+" "+line)
+			fmt.Fprintf(writer, "%s       |\n", padToWidth(prefix+" "+line, 90))
 		} else {
-			fmt.Fprintf(writer, "%-90s  |\n", prefix+" "+line)
+			fmt.Fprintf(writer, "%s  |\n", padToWidth(prefix+" "+line, 90))
 		}
 	}
 }
 
+// padToWidth pads text with spaces up to width terminal columns
+func padToWidth(text string, width int) string {
+	textWidth := lineWidth([]rune(text))
+	if textWidth >= width {
+		return text
+	}
+	return text + strings.Repeat(" ", width-textWidth)
+}
+
 // wrapText takes a string and breaks it into lines of maxWidth
 func wrapText(text string, maxWidth int) []string {
 	var wrappedLines []string
@@ -89,6 +136,10 @@ func wrapText(text string, maxWidth int) []string {
 func lineWidth(runes []rune) int {
 	width := 0
 	for _, r := range runes {
+		// Combining marks take no column of their own
+		if unicode.Is(unicode.Mn, r) {
+			continue
+		}
 		// Check the East Asian width of each rune
 		if eastasianwidth.IsFullwidth(r) {
 			width += 2
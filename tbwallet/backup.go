@@ -0,0 +1,153 @@
+// backup.go
+package tbwallet
+
+import (
+	"bufio"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"tbwallet/keys"
+	"tbwallet/tbfunctions"
+
+	"golang.org/x/term"
+)
+
+// SLIP-39 iteration exponent used for new backups, 2500 << 1 PBKDF2 rounds per Feistel round
+const slip39IterationExponent = 1
+
+// BackupShamir splits the wallet seed into SLIP-39 mnemonic shares
+func BackupShamir() {
+	threshold, shareCount := 0, 0
+	if value, isGiven := tbfunctions.FlagValue("--threshold"); isGiven {
+		threshold, _ = strconv.Atoi(value)
+	}
+	if value, isGiven := tbfunctions.FlagValue("--shares"); isGiven {
+		shareCount, _ = strconv.Atoi(value)
+	}
+	if threshold < 2 || shareCount < threshold || shareCount > slip39MaxShareCount {
+		tbfunctions.PrintBackupHelp()
+		return
+	}
+
+	wallet, _, err := loadWalletFile()
+	if err != nil {
+		fmt.Println("Error:", err)
+		return
+	}
+	seed, err := tbfunctions.UnlockSeed(wallet)
+	if err != nil {
+		fmt.Println("Error:", err)
+		return
+	}
+
+	fmt.Print("Enter SLIP-39 passphrase (Optional): ")
+	passphrase, err := term.ReadPassword(int(os.Stdin.Fd()))
+	fmt.Println()
+	if err != nil {
+		fmt.Println("Error reading passphrase:", err)
+		return
+	}
+
+	shares, err := SplitSlip39(seed, passphrase, threshold, shareCount, slip39IterationExponent)
+	if err != nil {
+		fmt.Println("Error creating shares:", err)
+		return
+	}
+
+	fmt.Printf("\n  Any %d of these %d shares recover the wallet. Give each share to a different custodian.\n", threshold, shareCount)
+	fmt.Println("  DON'T COPY/PASTE SHARES, WRITE THEM DOWN OR SAVE THEM IN AN OFFLINE PLACE")
+	for i, share := range shares {
+		tbfunctions.PrintShare(i+1, shareCount, share)
+	}
+}
+
+// RecoverWalletFromShamir collects SLIP-39 shares, rebuilds the seed and saves the wallet
+func RecoverWalletFromShamir() {
+	path, err := DerivationPathFromFlags()
+	if err != nil {
+		log.Fatal("Error: ", err)
+	}
+	reader := bufio.NewReader(os.Stdin)
+	var mnemonics []string
+	var seed []byte
+
+	for {
+		fmt.Printf("Enter share %d: ", len(mnemonics)+1)
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			log.Fatal("Error reading input:", err)
+		}
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		share, err := DecodeSlip39Share(line)
+		if err != nil {
+			fmt.Println("Invalid share:", err)
+			continue
+		}
+		mnemonic := strings.Join(strings.Fields(strings.ToLower(line)), " ")
+		if slices.Contains(mnemonics, mnemonic) {
+			fmt.Println("Share already entered")
+			continue
+		}
+		mnemonics = append(mnemonics, mnemonic)
+
+		// Check the shares fit together before asking for the passphrase
+		_, err = CombineSlip39(mnemonics, nil)
+		if err == ErrSlip39NotEnoughShares {
+			if share.GroupCount == 1 {
+				fmt.Printf("Share accepted, %d more needed\n", Slip39SharesNeeded(share, len(mnemonics)))
+			} else {
+				fmt.Println("Share accepted, more shares needed")
+			}
+			continue
+		} else if err != nil {
+			fmt.Println("Share rejected:", err)
+			mnemonics = mnemonics[:len(mnemonics)-1]
+			continue
+		}
+
+		fmt.Print("Enter SLIP-39 passphrase (Optional): ")
+		passphrase, err := term.ReadPassword(int(os.Stdin.Fd()))
+		fmt.Println()
+		if err != nil {
+			log.Fatal("Error reading passphrase:", err)
+		}
+		seed, err = CombineSlip39(mnemonics, passphrase)
+		if err != nil {
+			log.Fatal("Error recovering secret:", err)
+		}
+		break
+	}
+
+	// Rebuild the wallet through the normal derivation path
+	privateKey, uncompressedPublicKey, err := DeriveKeyPairWithPath(seed, path)
+	if err != nil {
+		log.Fatal("Error deriving key pair:", err)
+	}
+	config, err := tbfunctions.LoadConfig()
+	if err != nil {
+		log.Fatal("Error loading config:", err)
+	}
+	address, err := keys.AddressFromPublicKey(uncompressedPublicKey)
+	if err != nil {
+		log.Fatal("Error generating address:", err)
+	}
+
+	privateKeyHex := keys.PrivateKeyHex(privateKey)
+	publicKeyHex := hex.EncodeToString(uncompressedPublicKey)
+	tbfunctions.PrintWallet("", privateKeyHex, publicKeyHex, address, "RECOVERED")
+	fmt.Println("Derivation path:", FormatDerivationPath(path))
+
+	err = SaveDerivedWallet(config.WalletPath, privateKeyHex, seed, path)
+	if err != nil {
+		fmt.Println("Error saving wallet:", err)
+	}
+}
@@ -0,0 +1,177 @@
+// balance.go
+package tbwallet
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+
+	"tbwallet/amounts"
+	"tbwallet/keys"
+	"tbwallet/rpcclient"
+	"tbwallet/tbfunctions"
+)
+
+// Default refresh interval of balance --watch
+const balanceWatchInterval = 10 * time.Second
+
+// accountBalance is the confirmed and pending balance of an address in Hanas
+type accountBalance struct {
+	confirmed *big.Int
+	pending   *big.Int
+}
+
+func queryBalance(client *rpcclient.Client, address string) (accountBalance, error) {
+	ctx := context.Background()
+	confirmed, err := client.GetBalance(ctx, address, rpcclient.TagLatest)
+	if err != nil {
+		return accountBalance{}, err
+	}
+	pending, err := client.GetBalance(ctx, address, rpcclient.TagPending)
+	if err != nil {
+		return accountBalance{}, err
+	}
+	return accountBalance{confirmed: confirmed, pending: pending}, nil
+}
+
+// balanceAddress resolves the balance command address argument on network. A
+// Bech32m address brings its own network, which must agree with --network.
+func balanceAddress(input string, network string, isNetworkGiven bool) (string, string, error) {
+	if strings.HasPrefix(input, "@") {
+		contact, err := tbfunctions.ResolveNetworkContact(input, network)
+		if err != nil {
+			return "", "", err
+		}
+		return contact.Address, network, nil
+	}
+	if addressNetwork, isBech32 := tbfunctions.Bech32Network(input); isBech32 {
+		if isNetworkGiven && addressNetwork != network {
+			return "", "", fmt.Errorf("%s is a %s address but --network is %s", input, addressNetwork, network)
+		}
+		address, err := tbfunctions.ParseNetworkBech32Address(input, addressNetwork)
+		return address, addressNetwork, err
+	}
+	if len(input) != 42 || !strings.HasPrefix(input, "0x") || !tbfunctions.IsValidHex(input[2:]) {
+		return "", "", fmt.Errorf("%s is not a 0x hex or bech32 address", input)
+	}
+	if keys.VerifyAddressChecksum(input) == keys.ErrAddressChecksum {
+		return "", "", keys.ErrAddressChecksum
+	}
+	return strings.ToLower(input), network, nil
+}
+
+// ShowBalance runs "tbwallet balance [address]": the confirmed and pending
+// balance of the wallet address or of any address, once or with --watch
+// until interrupted
+func ShowBalance() {
+	config, err := tbfunctions.LoadConfig()
+	if err != nil {
+		fmt.Println("Error loading config:", err)
+		return
+	}
+	network, isNetworkGiven := tbfunctions.FlagValue("--network")
+	if !isNetworkGiven {
+		network = config.Network
+	} else if _, ok := tbfunctions.NetworkHRPs[network]; !ok {
+		fmt.Println("Error: unknown network, use mainnet or testnet:", network)
+		return
+	}
+
+	var address string
+	args := tbfunctions.PositionalArgs("--network", "--account", "--index", "--interval", "--unit")
+	if len(args) > 2 {
+		address, network, err = balanceAddress(args[2], network, isNetworkGiven)
+	} else {
+		var account, index uint32
+		account, index, err = ParseDerivationFlags("--index")
+		if err == nil {
+			address, _, err = WalletAddress(account, index)
+		}
+	}
+	if err != nil {
+		fmt.Println("Error:", err)
+		return
+	}
+
+	client, err := tbfunctions.NodeClient(network)
+	if err != nil {
+		fmt.Println("Error:", err)
+		return
+	}
+	balance, err := queryBalance(client, address)
+	if err != nil {
+		printBalanceError(config.RPCURL(network), err)
+		return
+	}
+	fmt.Println("Address   : " + tbfunctions.DisplayAddress(address))
+	fmt.Println("Network   : " + network)
+	unit := tbfunctions.DisplayUnit()
+	fmt.Println("Confirmed : " + formatBalance(balance.confirmed, unit))
+	fmt.Println("Pending   : " + formatBalance(balance.pending, unit))
+
+	if !tbfunctions.HasFlag("--watch") {
+		return
+	}
+	interval := balanceWatchInterval
+	if value, isGiven := tbfunctions.FlagValue("--interval"); isGiven {
+		seconds, err := strconv.Atoi(value)
+		if err != nil || seconds < 1 {
+			fmt.Println("Error: invalid interval:", value)
+			return
+		}
+		interval = time.Duration(seconds) * time.Second
+	}
+	fmt.Printf("\nWatching every %s, press Ctrl+C to stop\n", interval)
+	watchBalance(client, address, balance, interval, unit)
+}
+
+// watchBalance polls the balance and prints a line whenever it changes
+func watchBalance(client *rpcclient.Client, address string, last accountBalance, interval time.Duration, unit string) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		balance, err := queryBalance(client, address)
+		now := time.Now().Format("15:04:05")
+		if err != nil {
+			fmt.Printf("[%s] Error: %v\n", now, err)
+			continue
+		}
+		if balance.confirmed.Cmp(last.confirmed) == 0 && balance.pending.Cmp(last.pending) == 0 {
+			continue
+		}
+		fmt.Printf("[%s] Confirmed %s (%s)  Pending %s (%s)\n", now,
+			amounts.Format(balance.confirmed, unit), formatChange(last.confirmed, balance.confirmed, unit),
+			amounts.Format(balance.pending, unit), formatChange(last.pending, balance.pending, unit))
+		last = balance
+	}
+}
+
+// formatBalance formats a balance in both units, unit first: "1.5 TBYT (15000000 Hanas)"
+func formatBalance(hanas *big.Int, unit string) string {
+	otherUnit := amounts.UnitHanas
+	if unit == amounts.UnitHanas {
+		otherUnit = amounts.UnitTBYT
+	}
+	return amounts.Format(hanas, unit) + " (" + amounts.Format(hanas, otherUnit) + ")"
+}
+
+// formatChange formats the difference between two balances in unit with a sign
+func formatChange(before *big.Int, after *big.Int, unit string) string {
+	change := new(big.Int).Sub(after, before)
+	if change.Sign() > 0 {
+		return "+" + amounts.FormatValue(change, unit)
+	}
+	return amounts.FormatValue(change, unit)
+}
+
+func printBalanceError(node string, err error) {
+	fmt.Println(`
++--------------------------------------------+
+| Error: Can't query RPC node for balance    |
++--------------------------------------------+
+  Node   : ` + node + `
+  Reason : ` + err.Error())
+}
@@ -0,0 +1,166 @@
+// derivation.go
+package tbwallet
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+
+	"tbwallet/tbfunctions"
+
+	"github.com/tyler-smith/go-bip32"
+)
+
+// DefaultDerivationPath is the BIP-44 path of Tulobyte wallets
+const DefaultDerivationPath = "m/44'/202'/0'/0/0"
+
+// DerivationPresets maps preset names to derivation paths of common wallets
+var DerivationPresets = map[string]string{
+	"tulobyte":      DefaultDerivationPath,
+	"ethereum":      "m/44'/60'/0'/0/0",
+	"ledger-legacy": "m/44'/60'/0'/0",
+}
+
+// ParseDerivationPath parses a BIP-32 path such as m/44'/60'/0'/0/0. Hardened
+// indexes are marked with ' or h.
+func ParseDerivationPath(path string) ([]uint32, error) {
+	parts := strings.Split(strings.TrimSpace(path), "/")
+	if len(parts) < 2 || parts[0] != "m" {
+		return nil, fmt.Errorf("invalid derivation path %q, it must look like m/44'/202'/0'/0/0", path)
+	}
+
+	var indexes []uint32
+	for _, part := range parts[1:] {
+		hardened := strings.HasSuffix(part, "'") || strings.HasSuffix(part, "h") || strings.HasSuffix(part, "H")
+		if hardened {
+			part = part[:len(part)-1]
+		}
+		index, err := strconv.ParseUint(part, 10, 31)
+		if err != nil {
+			return nil, fmt.Errorf("invalid index %q in derivation path %q", part, path)
+		}
+		if hardened {
+			index += uint64(bip32.FirstHardenedChild)
+		}
+		indexes = append(indexes, uint32(index))
+	}
+	return indexes, nil
+}
+
+// FormatDerivationPath formats indexes as a BIP-32 path string
+func FormatDerivationPath(indexes []uint32) string {
+	var path strings.Builder
+	path.WriteString("m")
+	for _, index := range indexes {
+		if index >= bip32.FirstHardenedChild {
+			fmt.Fprintf(&path, "/%d'", index-bip32.FirstHardenedChild)
+		} else {
+			fmt.Fprintf(&path, "/%d", index)
+		}
+	}
+	return path.String()
+}
+
+// DerivationPathFromFlags reads --path or --preset from the command line,
+// falling back to the Tulobyte BIP-44 path
+func DerivationPathFromFlags() ([]uint32, error) {
+	path, isPathGiven := tbfunctions.FlagValue("--path")
+	preset, isPresetGiven := tbfunctions.FlagValue("--preset")
+	if isPathGiven && isPresetGiven {
+		return nil, errors.New("use either --path or --preset, not both")
+	}
+	if isPresetGiven {
+		presetPath, ok := DerivationPresets[strings.ToLower(preset)]
+		if !ok {
+			names := slices.Sorted(maps.Keys(DerivationPresets))
+			return nil, fmt.Errorf("unknown preset %q, choose one of: %s", preset, strings.Join(names, ", "))
+		}
+		path = presetPath
+	} else if !isPathGiven {
+		path = DefaultDerivationPath
+	}
+	return ParseDerivationPath(path)
+}
+
+// isAccountPath reports whether a path has the BIP-44 shape
+// purpose'/coin'/account'/change/index that accounts and indexes can vary
+func isAccountPath(path []uint32) bool {
+	return len(path) == 5 &&
+		path[0] >= bip32.FirstHardenedChild &&
+		path[1] >= bip32.FirstHardenedChild &&
+		path[2] >= bip32.FirstHardenedChild &&
+		path[3] < bip32.FirstHardenedChild &&
+		path[4] < bip32.FirstHardenedChild
+}
+
+// walletDerivationPath returns the path stored with the wallet, wallets saved
+// before paths were stored use the Tulobyte path
+func walletDerivationPath(wallet tbfunctions.WalletFile) ([]uint32, error) {
+	if wallet.Path == "" {
+		return ParseDerivationPath(DefaultDerivationPath)
+	}
+	return ParseDerivationPath(wallet.Path)
+}
+
+// walletAccountPath returns the account level path purpose'/coin'/account' of the
+// wallet path for the given account
+func walletAccountPath(path []uint32, account uint32) ([]uint32, error) {
+	if !isAccountPath(path) {
+		return nil, fmt.Errorf("wallet path %s has no BIP-44 account level, --account and --index can't be used", FormatDerivationPath(path))
+	}
+	if account >= bip32.FirstHardenedChild {
+		return nil, fmt.Errorf("account %d out of range", account)
+	}
+	return []uint32{path[0], path[1], account + bip32.FirstHardenedChild}, nil
+}
+
+// pathAccountIndex returns the account and index of a BIP-44 shaped path
+func pathAccountIndex(path []uint32) (uint32, uint32) {
+	if !isAccountPath(path) {
+		return 0, 0
+	}
+	return path[2] - bip32.FirstHardenedChild, path[4]
+}
+
+// SaveDerivedWallet encrypts the key derived at path together with the seed and
+// writes the wallet file, caching the xpub of the path account
+func SaveDerivedWallet(walletFile string, privateKeyHex string, seed []byte, path []uint32) error {
+	var accounts map[string]*tbfunctions.WalletAccount
+	if isAccountPath(path) {
+		account, index := pathAccountIndex(path)
+		accountPath, err := walletAccountPath(path, account)
+		if err != nil {
+			return err
+		}
+		accountXPub, err := AccountXPub(seed, accountPath)
+		if err != nil {
+			return fmt.Errorf("error deriving account key: %w", err)
+		}
+		accounts = map[string]*tbfunctions.WalletAccount{
+			strconv.FormatUint(uint64(account), 10): {XPub: accountXPub, Cursor: index + 1},
+		}
+	}
+	return tbfunctions.SaveSeedWallet(walletFile, privateKeyHex, seed, FormatDerivationPath(path), accounts)
+}
+
+// accountIndexPath substitutes account and index into a BIP-44 shaped wallet path
+func accountIndexPath(path []uint32, account uint32, index uint32) ([]uint32, error) {
+	accountPath, err := walletAccountPath(path, account)
+	if err != nil {
+		return nil, err
+	}
+	if index >= bip32.FirstHardenedChild {
+		return nil, fmt.Errorf("index %d out of range", index)
+	}
+	return append(accountPath, path[3], index), nil
+}
+
+// isWalletDefault reports whether account and index select the key stored in the
+// wallet Crypto field rather than one derived from the seed
+func isWalletDefault(path []uint32, account uint32, index uint32) bool {
+	pathAccount, pathIndex := pathAccountIndex(path)
+	return account == pathAccount && index == pathIndex
+}
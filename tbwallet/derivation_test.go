@@ -0,0 +1,78 @@
+// derivation_test.go
+package tbwallet
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/tyler-smith/go-bip32"
+)
+
+// hardened is added to an index to mark it hardened
+const hardened = bip32.FirstHardenedChild
+
+func TestParseDerivationPath(t *testing.T) {
+	tests := []struct {
+		path string
+		want []uint32
+	}{
+		{"m/44'/202'/0'/0/0", []uint32{44 + hardened, 202 + hardened, hardened, 0, 0}},
+		{"m/44h/60H/1'/1/7", []uint32{44 + hardened, 60 + hardened, 1 + hardened, 1, 7}},
+		{"m/44'/60'/0'/0", []uint32{44 + hardened, 60 + hardened, hardened, 0}},
+		{"m/0", []uint32{0}},
+		{" m/1/2 ", []uint32{1, 2}},
+		{"m/2147483647", []uint32{2147483647}},
+		{"m/2147483647'", []uint32{0xffffffff}},
+	}
+	for _, test := range tests {
+		got, err := ParseDerivationPath(test.path)
+		if err != nil || !reflect.DeepEqual(got, test.want) {
+			t.Errorf("ParseDerivationPath(%q) = %v, %v, want %v", test.path, got, err, test.want)
+		}
+	}
+}
+
+func TestParseDerivationPathErrors(t *testing.T) {
+	paths := []string{
+		"",
+		"m",
+		"m/",
+		"44'/60'/0'/0/0",
+		"M/44'/60'",
+		"m/44''/60'",
+		"m/-1",
+		"m/+1",
+		"m/a",
+		"m/44'//0",
+		"m/44x",
+		"m/2147483648",
+		"m/2147483648'",
+		"m/44'/60'/0'/0/0/",
+	}
+	for _, path := range paths {
+		if got, err := ParseDerivationPath(path); err == nil {
+			t.Errorf("ParseDerivationPath(%q) = %v, want an error", path, got)
+		}
+	}
+}
+
+func TestFormatDerivationPath(t *testing.T) {
+	if got := FormatDerivationPath(nil); got != "m" {
+		t.Errorf("FormatDerivationPath(nil) = %s, want m", got)
+	}
+	paths := []string{DefaultDerivationPath, "m/44'/60'/0'/0", "m/0/1"}
+	for _, path := range paths {
+		indexes, err := ParseDerivationPath(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := FormatDerivationPath(indexes); got != path {
+			t.Errorf("FormatDerivationPath(%v) = %s, want %s", indexes, got, path)
+		}
+	}
+	// h marked indexes are written with '
+	indexes, _ := ParseDerivationPath("m/44h/60h")
+	if got := FormatDerivationPath(indexes); got != "m/44'/60'" {
+		t.Errorf("FormatDerivationPath(%v) = %s, want m/44'/60'", indexes, got)
+	}
+}
@@ -0,0 +1,84 @@
+// export.go
+package tbwallet
+
+import (
+	"encoding/hex"
+	"fmt"
+
+	"tbwallet/tbfunctions"
+)
+
+// ExportKeystore writes the wallet private key to a Web3 Secret Storage (v3) JSON file
+func ExportKeystore(keystorePath string, kdf string) {
+	if kdf != "scrypt" && kdf != "pbkdf2" {
+		fmt.Println("Error: kdf must be scrypt or pbkdf2")
+		return
+	}
+
+	address, isFound := tbfunctions.ShowWalletInfo("address")
+	if !isFound {
+		return
+	}
+	privateKeyHex, isFound := tbfunctions.GetPrivateKey()
+	if !isFound {
+		return
+	}
+	privateKeyBytes, err := hex.DecodeString(privateKeyHex)
+	if err != nil {
+		fmt.Println("Failed to decode private key:", err)
+		return
+	}
+
+	fmt.Println("Choose a password for the exported keystore")
+	password, err := tbfunctions.ReadNewPassword()
+	if err != nil {
+		fmt.Println("Error:", err)
+		return
+	}
+
+	keystore, err := tbfunctions.EncryptKeystoreV3(privateKeyBytes, address, password, kdf)
+	if err != nil {
+		fmt.Println("Error creating keystore:", err)
+		return
+	}
+	err = tbfunctions.WriteKeystoreV3(keystorePath, keystore)
+	if err != nil {
+		fmt.Println("Error saving keystore:", err)
+		return
+	}
+	fmt.Println("Keystore exported to:", keystorePath)
+}
+
+// ExportXPub prints the account level extended public key, which lets a
+// watch-only wallet derive the account addresses without any private key
+func ExportXPub() {
+	account, _, err := ParseDerivationFlags("--index")
+	if err != nil {
+		fmt.Println("Error:", err)
+		return
+	}
+	wallet, walletPath, err := loadWalletFile()
+	if err != nil {
+		fmt.Println("Error:", err)
+		return
+	}
+	path, err := walletDerivationPath(wallet)
+	if err != nil {
+		fmt.Println("Error:", err)
+		return
+	}
+	accountPath, err := walletAccountPath(path, account)
+	if err != nil {
+		fmt.Println("Error:", err)
+		return
+	}
+	xpub, err := accountXPub(&wallet, walletPath, account)
+	if err != nil {
+		fmt.Println("Error:", err)
+		return
+	}
+
+	keyPath, _ := accountIndexPath(path, account, 0)
+	fmt.Printf("Account xpub (%s): %s\n", FormatDerivationPath(accountPath), xpub)
+	fmt.Printf("\nImport it on the watching machine with:\n  tbwallet recover --xpub %s --path \"%s\"\n", xpub, FormatDerivationPath(keyPath))
+}
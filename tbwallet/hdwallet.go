@@ -0,0 +1,345 @@
+// hdwallet.go
+package tbwallet
+
+import (
+	"crypto/ecdsa"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"tbwallet/keys"
+	"tbwallet/tbfunctions"
+
+	"github.com/tyler-smith/go-bip32"
+)
+
+// deriveKey derives the extended private key at path from the seed
+func deriveKey(seed []byte, path []uint32) (*bip32.Key, error) {
+	if len(seed) < 32 {
+		return nil, errors.New("seed must be at least 32 bytes long")
+	}
+
+	masterKey, err := bip32.NewMasterKey(seed)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate master key: %v", err)
+	}
+
+	childKey := masterKey
+	for _, index := range path {
+		childKey, err = childKey.NewChildKey(index)
+		if err != nil {
+			return nil, fmt.Errorf("failed to derive keys: %v", err)
+		}
+	}
+	return childKey, nil
+}
+
+// AccountXPub returns the serialized extended public key at the account path purpose'/coin'/account'
+func AccountXPub(seed []byte, accountPath []uint32) (string, error) {
+	accountKey, err := deriveKey(seed, accountPath)
+	if err != nil {
+		return "", err
+	}
+	return accountKey.PublicKey().B58Serialize(), nil
+}
+
+// AddressFromXPub derives the address and public key at change/index from an account xpub
+func AddressFromXPub(xpub string, change uint32, index uint32) (string, []byte, error) {
+	if index >= bip32.FirstHardenedChild {
+		return "", nil, fmt.Errorf("index %d out of range", index)
+	}
+	accountKey, err := bip32.B58Deserialize(xpub)
+	if err != nil {
+		return "", nil, fmt.Errorf("invalid extended public key: %v", err)
+	}
+	childKey := accountKey.PublicKey()
+	for _, childIndex := range []uint32{change, index} {
+		childKey, err = childKey.NewChildKey(childIndex)
+		if err != nil {
+			return "", nil, fmt.Errorf("failed to derive public key: %v", err)
+		}
+	}
+
+	publicKey, err := keys.ParsePublicKey(childKey.Key)
+	if err != nil {
+		return "", nil, err
+	}
+	return keys.Address(publicKey), keys.PublicKeyBytes(publicKey), nil
+}
+
+// loadWalletFile loads the configured wallet file, legacy plaintext wallets have no HD data
+func loadWalletFile() (tbfunctions.WalletFile, string, error) {
+	config, err := tbfunctions.LoadConfig()
+	if err != nil {
+		return tbfunctions.WalletFile{}, "", fmt.Errorf("error loading config: %w", err)
+	}
+	wallet, legacyKey, err := tbfunctions.LoadWallet(config.WalletPath)
+	if err != nil {
+		return wallet, "", err
+	}
+	if legacyKey != nil {
+		return wallet, "", errors.New(`wallet is not encrypted, run "tbwallet encrypt" first`)
+	}
+	tbfunctions.CheckAddressMigration(wallet)
+	return wallet, config.WalletPath, nil
+}
+
+// accountXPub returns the cached xpub of an account, unlocking the seed and
+// caching the xpub in the wallet file when the account is used for the first time
+func accountXPub(wallet *tbfunctions.WalletFile, walletPath string, account uint32) (string, error) {
+	accountName := strconv.FormatUint(uint64(account), 10)
+	if walletAccount, ok := wallet.Accounts[accountName]; ok && walletAccount.XPub != "" {
+		return walletAccount.XPub, nil
+	}
+	if wallet.WatchOnly {
+		return "", fmt.Errorf("watch-only wallet has no xpub for account %d", account)
+	}
+
+	path, err := walletDerivationPath(*wallet)
+	if err != nil {
+		return "", err
+	}
+	accountPath, err := walletAccountPath(path, account)
+	if err != nil {
+		return "", err
+	}
+	seed, err := tbfunctions.UnlockSeed(*wallet)
+	if err != nil {
+		return "", err
+	}
+	xpub, err := AccountXPub(seed, accountPath)
+	if err != nil {
+		return "", err
+	}
+	if wallet.Accounts == nil {
+		wallet.Accounts = map[string]*tbfunctions.WalletAccount{}
+	}
+	wallet.Accounts[accountName] = &tbfunctions.WalletAccount{XPub: xpub}
+	err = tbfunctions.SaveWallet(walletPath, *wallet)
+	if err != nil {
+		return "", err
+	}
+	return xpub, nil
+}
+
+// WalletAddress returns the address and public key hex at account and index of the wallet path
+func WalletAddress(account uint32, index uint32) (string, string, error) {
+	wallet, walletPath, err := loadWalletFile()
+	if err != nil {
+		return "", "", err
+	}
+	path, err := walletDerivationPath(wallet)
+	if err != nil {
+		return "", "", err
+	}
+	if isWalletDefault(path, account, index) {
+		return wallet.Address, wallet.PublicKey, nil
+	}
+	xpub, err := accountXPub(&wallet, walletPath, account)
+	if err != nil {
+		return "", "", err
+	}
+	address, publicKey, err := AddressFromXPub(xpub, path[3], index)
+	if err != nil {
+		return "", "", err
+	}
+	return address, hex.EncodeToString(publicKey), nil
+}
+
+// NextReceiveAddress hands out the address at the account cursor and advances the cursor
+func NextReceiveAddress(account uint32) (string, uint32, error) {
+	wallet, walletPath, err := loadWalletFile()
+	if err != nil {
+		return "", 0, err
+	}
+	path, err := walletDerivationPath(wallet)
+	if err != nil {
+		return "", 0, err
+	}
+	xpub, err := accountXPub(&wallet, walletPath, account)
+	if err != nil {
+		return "", 0, err
+	}
+	walletAccount := wallet.Accounts[strconv.FormatUint(uint64(account), 10)]
+	index := walletAccount.Cursor
+	address, _, err := AddressFromXPub(xpub, path[3], index)
+	if err != nil {
+		return "", 0, err
+	}
+	walletAccount.Cursor = index + 1
+	err = tbfunctions.SaveWallet(walletPath, wallet)
+	if err != nil {
+		return "", 0, err
+	}
+	return address, index, nil
+}
+
+// UnlockSigningKey prompts for the password and returns the private key and
+// address at account and index of the wallet path
+func UnlockSigningKey(account uint32, index uint32) (*ecdsa.PrivateKey, string, error) {
+	wallet, _, err := loadWalletFile()
+	if err != nil {
+		return nil, "", err
+	}
+	path, err := walletDerivationPath(wallet)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if isWalletDefault(path, account, index) {
+		privateKeyBytes, err := tbfunctions.UnlockPrivateKey(wallet)
+		if err != nil {
+			return nil, "", err
+		}
+		privateKey, err := keys.PrivateKeyFromBytes(privateKeyBytes)
+		if err != nil {
+			return nil, "", err
+		}
+		return privateKey, wallet.Address, nil
+	}
+
+	keyPath, err := accountIndexPath(path, account, index)
+	if err != nil {
+		return nil, "", err
+	}
+	seed, err := tbfunctions.UnlockSeed(wallet)
+	if err != nil {
+		return nil, "", err
+	}
+	privateKey, publicKey, err := DeriveKeyPairWithPath(seed, keyPath)
+	if err != nil {
+		return nil, "", err
+	}
+	address, err := keys.AddressFromPublicKey(publicKey)
+	if err != nil {
+		return nil, "", err
+	}
+	return privateKey, address, nil
+}
+
+// ParseDerivationFlags reads --account and the given index flag from the command line.
+// Flags that aren't given default to the account and index of the wallet path.
+func ParseDerivationFlags(indexFlag string) (uint32, uint32, error) {
+	var account, index uint32
+	if wallet, _, err := loadWalletFile(); err == nil {
+		if path, err := walletDerivationPath(wallet); err == nil {
+			account, index = pathAccountIndex(path)
+		}
+	}
+	if value, isGiven := tbfunctions.FlagValue("--account"); isGiven {
+		parsed, err := strconv.ParseUint(value, 10, 31)
+		if err != nil {
+			return 0, 0, fmt.Errorf("invalid account: %s", value)
+		}
+		account = uint32(parsed)
+	}
+	if value, isGiven := tbfunctions.FlagValue(indexFlag); isGiven {
+		parsed, err := strconv.ParseUint(value, 10, 31)
+		if err != nil {
+			return 0, 0, fmt.Errorf("invalid index: %s", value)
+		}
+		index = uint32(parsed)
+	}
+	return account, index, nil
+}
+
+// ShowAddress prints the wallet address selected by the address command flags
+func ShowAddress() {
+	format, isGiven := tbfunctions.FlagValue("--format")
+	if !isGiven {
+		format = "hex"
+	}
+	if format != "hex" && format != "bech32" {
+		fmt.Printf("Error: unknown address format %q, use bech32 or hex\n", format)
+		return
+	}
+
+	if tbfunctions.HasFlag("--path", "--preset") {
+		showPathAddress(format)
+		return
+	}
+
+	account, index, err := ParseDerivationFlags("--index")
+	if err != nil {
+		fmt.Println("Error:", err)
+		return
+	}
+
+	if !tbfunctions.HasFlag("--new", "--account", "--index") {
+		address, isFound := tbfunctions.ShowWalletInfo("address")
+		if !isFound {
+			return
+		}
+		printWalletAddress("Wallet Address", address, format)
+		return
+	}
+
+	wallet, _, err := loadWalletFile()
+	if err != nil {
+		fmt.Println("Error:", err)
+		return
+	}
+	path, err := walletDerivationPath(wallet)
+	if err != nil {
+		fmt.Println("Error:", err)
+		return
+	}
+
+	var address string
+	if tbfunctions.HasFlag("--new") {
+		address, index, err = NextReceiveAddress(account)
+	} else {
+		address, _, err = WalletAddress(account, index)
+	}
+	if err != nil {
+		fmt.Println("Error:", err)
+		return
+	}
+	keyPath, err := accountIndexPath(path, account, index)
+	if err != nil {
+		fmt.Println("Error:", err)
+		return
+	}
+	printWalletAddress("Wallet Address ("+FormatDerivationPath(keyPath)+")", address, format)
+}
+
+// showPathAddress derives the address at the --path or --preset path from the wallet seed
+func showPathAddress(format string) {
+	path, err := DerivationPathFromFlags()
+	if err != nil {
+		fmt.Println("Error:", err)
+		return
+	}
+	wallet, _, err := loadWalletFile()
+	if err != nil {
+		fmt.Println("Error:", err)
+		return
+	}
+	seed, err := tbfunctions.UnlockSeed(wallet)
+	if err != nil {
+		fmt.Println("Error:", err)
+		return
+	}
+	_, publicKey, err := DeriveKeyPairWithPath(seed, path)
+	if err != nil {
+		fmt.Println("Error:", err)
+		return
+	}
+	address, err := keys.AddressFromPublicKey(publicKey)
+	if err != nil {
+		fmt.Println("Error:", err)
+		return
+	}
+	printWalletAddress("Wallet Address ("+FormatDerivationPath(path)+")", address, format)
+}
+
+// printWalletAddress prints an address as EIP-55 hex or Bech32m
+func printWalletAddress(label string, address string, format string) {
+	formatted, err := tbfunctions.FormatAddress(address, format)
+	if err != nil {
+		fmt.Println("Error:", err)
+		return
+	}
+	fmt.Printf("%s: %s\n", label, formatted)
+}
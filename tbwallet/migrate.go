@@ -0,0 +1,81 @@
+// migrate.go
+package tbwallet
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strconv"
+
+	"tbwallet/keys"
+	"tbwallet/tbfunctions"
+)
+
+// MigrateWallet recomputes the stored public key and address of wallets saved
+// by versions that hashed unpadded public keys
+func MigrateWallet() {
+	config, err := tbfunctions.LoadConfig()
+	if err != nil {
+		fmt.Println("Error loading config:", err)
+		return
+	}
+	wallet, legacyKey, err := tbfunctions.LoadWallet(config.WalletPath)
+	if err != nil {
+		fmt.Println("Can't read wallet file:", err)
+		return
+	}
+	if legacyKey != nil {
+		// Encrypting stores the address computed from the padded key
+		tbfunctions.EncryptLegacyWallet()
+		return
+	}
+	if !tbfunctions.AddressNeedsMigration(wallet) {
+		fmt.Println("Wallet address is up to date")
+		return
+	}
+
+	var address string
+	var publicKey []byte
+	if wallet.WatchOnly {
+		path, err := walletDerivationPath(wallet)
+		if err != nil {
+			fmt.Println("Error:", err)
+			return
+		}
+		account, _ := pathAccountIndex(path)
+		walletAccount, ok := wallet.Accounts[strconv.FormatUint(uint64(account), 10)]
+		if !ok {
+			fmt.Println("Error: watch-only wallet has no xpub for its default address")
+			return
+		}
+		address, publicKey, err = AddressFromXPub(walletAccount.XPub, path[3], path[4])
+		if err != nil {
+			fmt.Println("Error:", err)
+			return
+		}
+	} else {
+		privateKeyBytes, err := tbfunctions.UnlockPrivateKey(wallet)
+		if err != nil {
+			fmt.Println("Error:", err)
+			return
+		}
+		privateKey, err := keys.PrivateKeyFromBytes(privateKeyBytes)
+		if err != nil {
+			fmt.Println("Error:", err)
+			return
+		}
+		address = keys.Address(&privateKey.PublicKey)
+		publicKey = keys.PublicKeyBytes(&privateKey.PublicKey)
+	}
+
+	oldAddress := wallet.Address
+	wallet.Address = address
+	wallet.PublicKey = hex.EncodeToString(publicKey)
+	err = tbfunctions.SaveWallet(config.WalletPath, wallet)
+	if err != nil {
+		fmt.Println("Error saving wallet:", err)
+		return
+	}
+	fmt.Println("Old address:", tbfunctions.DisplayAddress(oldAddress))
+	fmt.Println("New address:", tbfunctions.DisplayAddress(address))
+	fmt.Println("Wallet migrated. Share the new address from now on, the old one is not the address of your key.")
+}
@@ -3,57 +3,139 @@ package tbwallet
 
 import (
 	"crypto/rand"
+	"crypto/sha256"
+	_ "embed"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"math/big"
-	"os"
 	"strings"
 )
 
-// LoadWordlist loads a plain array of words from the JSON file
-func LoadWordlist(filename string) ([]string, error) {
-	data, err := os.ReadFile(filename)
-	if err != nil {
-		return nil, err
-	}
+// English BIP-39 wordlist, embedded so create works from any directory
+//
+//go:embed wordlist.json
+var wordlistJSON []byte
+
+// ErrMnemonicChecksum is returned when a phrase has valid words but a wrong BIP-39 checksum
+var ErrMnemonicChecksum = errors.New("recovery phrase checksum is invalid")
 
+// LoadWordlist parses the embedded BIP-39 wordlist
+func LoadWordlist() ([]string, error) {
 	var wordlist []string
-	err = json.Unmarshal(data, &wordlist)
+	err := json.Unmarshal(wordlistJSON, &wordlist)
 	if err != nil {
 		return nil, err
 	}
+	if len(wordlist) != 2048 {
+		return nil, fmt.Errorf("wordlist must have 2048 words, found %d", len(wordlist))
+	}
 
 	return wordlist, nil
 }
 
-// CreateMnemonic generates a random mnemonic from the wordlist using crypto/rand
-func CreateMnemonic(wordlist []string, wordCount int) (string, error) {
-	var mnemonic []string
-	for i := 0; i < wordCount; i++ {
-		// Generate a cryptographically secure random index
-		randomIndex, err := rand.Int(rand.Reader, big.NewInt(int64(len(wordlist))))
-		if err != nil {
-			return "", err
+// EntropyToMnemonic encodes 128 to 256 bits of entropy as a BIP-39 phrase.
+// A checksum of entropy bits / 32 taken from SHA-256 is appended and the
+// result is split into 11 bit word indexes.
+func EntropyToMnemonic(entropy []byte, wordlist []string) (string, error) {
+	entropyBits := len(entropy) * 8
+	if entropyBits < 128 || entropyBits > 256 || entropyBits%32 != 0 {
+		return "", fmt.Errorf("entropy must be 128 to 256 bits in steps of 32, got %d", entropyBits)
+	}
+	checksumBits := entropyBits / 32
+	hash := sha256.Sum256(entropy)
+
+	// Entropy followed by the checksum bits as one big number
+	data := new(big.Int).SetBytes(entropy)
+	data.Lsh(data, uint(checksumBits))
+	data.Or(data, big.NewInt(int64(hash[0]>>(8-checksumBits))))
+
+	wordCount := (entropyBits + checksumBits) / 11
+	words := make([]string, wordCount)
+	mask := big.NewInt(2047)
+	for i := wordCount - 1; i >= 0; i-- {
+		index := new(big.Int).And(data, mask)
+		words[i] = wordlist[index.Int64()]
+		data.Rsh(data, 11)
+	}
+	return strings.Join(words, " "), nil
+}
+
+// MnemonicToEntropy decodes a BIP-39 phrase and verifies its checksum
+func MnemonicToEntropy(mnemonic string, wordlist []string) ([]byte, error) {
+	words := strings.Fields(mnemonic)
+	if len(words) < 12 || len(words) > 24 || len(words)%3 != 0 {
+		return nil, fmt.Errorf("recovery phrase must have 12, 15, 18, 21 or 24 words, got %d", len(words))
+	}
+
+	wordIndex := make(map[string]int, len(wordlist))
+	for i, word := range wordlist {
+		wordIndex[word] = i
+	}
+
+	data := new(big.Int)
+	for _, word := range words {
+		index, ok := wordIndex[word]
+		if !ok {
+			return nil, fmt.Errorf("word %q is not in the wordlist", word)
 		}
+		data.Lsh(data, 11)
+		data.Or(data, big.NewInt(int64(index)))
+	}
+
+	checksumBits := len(words) * 11 / 33
+	entropyBytes := checksumBits * 4
+	checksum := new(big.Int).And(data, big.NewInt(int64(1<<checksumBits)-1))
+	data.Rsh(data, uint(checksumBits))
+
+	entropy := make([]byte, entropyBytes)
+	data.FillBytes(entropy)
+
+	hash := sha256.Sum256(entropy)
+	if int64(hash[0]>>(8-checksumBits)) != checksum.Int64() {
+		return nil, ErrMnemonicChecksum
+	}
+	return entropy, nil
+}
 
-		mnemonic = append(mnemonic, wordlist[randomIndex.Int64()])
+// CreateMnemonic generates a BIP-39 mnemonic of wordCount words from crypto/rand entropy
+func CreateMnemonic(wordlist []string, wordCount int) (string, error) {
+	if wordCount < 12 || wordCount > 24 || wordCount%3 != 0 {
+		return "", fmt.Errorf("mnemonic length must be 12, 15, 18, 21 or 24 words, got %d", wordCount)
+	}
+	// Every 3 words carry 32 bits of entropy and 1 checksum bit
+	entropy := make([]byte, wordCount/3*4)
+	if _, err := rand.Read(entropy); err != nil {
+		return "", err
 	}
-	// Join the words together with spaces and return it as a string
-	return strings.Join(mnemonic, " "), nil
+	return EntropyToMnemonic(entropy, wordlist)
 }
 
-// GenerateMnemonic generates a random mnemonic and returns it as a string
-func GenerateMnemonic(wordCount int) (string, error) {
-	// Load wordlist from JSON file
-	wordlist, err := LoadWordlist("wordlist.json")
+// GenerateMnemonic generates a random mnemonic in the given language and returns it as a string
+func GenerateMnemonic(wordCount int, language string) (string, error) {
+	// Load the wordlist of the language
+	wordlist, err := LoadLanguageWordlist(language)
 	if err != nil {
 		return "", err
 	}
 
 	// Generate a random mnemonic
-	mnemonic, err := CreateMnemonic(wordlist, wordCount) // 12-word mnemonic
+	mnemonic, err := CreateMnemonic(wordlist, wordCount)
 	if err != nil {
 		return "", err
 	}
 
-	return mnemonic, nil
+	return JoinMnemonic(strings.Fields(mnemonic), language), nil
+}
+
+// ValidateMnemonic checks that every word is in the wordlist of the detected
+// language and the checksum matches
+func ValidateMnemonic(mnemonic string) error {
+	words := NormalizeMnemonic(mnemonic)
+	_, wordlist, err := DetectLanguage(words)
+	if err != nil {
+		return err
+	}
+	_, err = MnemonicToEntropy(strings.Join(words, " "), wordlist)
+	return err
 }
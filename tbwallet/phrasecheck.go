@@ -0,0 +1,178 @@
+// phrasecheck.go
+package tbwallet
+
+import (
+	"bufio"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// Maximum number of suggestions shown for a misspelled word
+const maxSuggestions = 5
+
+// SuggestWords returns the wordlist entries closest to word. BIP-39 words are
+// unique in their first 4 letters, so prefix matches come first, followed by
+// words within an edit distance of 2.
+func SuggestWords(word string, wordlist []string) []string {
+	type candidate struct {
+		word     string
+		distance int
+	}
+	var candidates []candidate
+	prefix := []rune(word)
+	if len(prefix) > 4 {
+		prefix = prefix[:4]
+	}
+	for _, listWord := range wordlist {
+		distance := editDistance(word, listWord)
+		if len(prefix) == 4 && strings.HasPrefix(listWord, string(prefix)) {
+			// Prefix matches rank ahead of any edit distance match
+			candidates = append(candidates, candidate{listWord, -1})
+		} else if distance <= 2 {
+			candidates = append(candidates, candidate{listWord, distance})
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].distance < candidates[j].distance
+	})
+
+	var suggestions []string
+	for i := 0; i < len(candidates) && i < maxSuggestions; i++ {
+		suggestions = append(suggestions, candidates[i].word)
+	}
+	return suggestions
+}
+
+// editDistance is the Levenshtein distance between a and b
+func editDistance(a string, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	previous := make([]int, len(rb)+1)
+	current := make([]int, len(rb)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		current[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(rb)]
+}
+
+// ReviewMnemonic detects the phrase language, checks every word against its
+// wordlist and the BIP-39 checksum, and lets the user fix words interactively
+// until it is valid. The phrase is returned NFKD normalized.
+func ReviewMnemonic(reader *bufio.Reader, mnemonic string) (string, error) {
+	words := NormalizeMnemonic(mnemonic)
+	language, wordlist, err := DetectLanguage(words)
+	if err != nil {
+		return "", err
+	}
+	wordIndex := make(map[string]bool, len(wordlist))
+	matches := 0
+	for _, word := range wordlist {
+		wordIndex[word] = true
+	}
+	for _, word := range words {
+		if wordIndex[word] {
+			matches++
+		}
+	}
+	if matches == 0 {
+		fmt.Println("No word of the phrase is in a bundled wordlist (" + strings.Join(Languages, ", ") + ").")
+		fmt.Println("Portuguese phrases can't be recovered yet, its wordlist isn't bundled.")
+	} else if language != "english" {
+		fmt.Println("Recovery phrase language:", language)
+	}
+
+	for {
+		if len(words) < 12 || len(words) > 24 || len(words)%3 != 0 {
+			return "", fmt.Errorf("recovery phrase must have 12, 15, 18, 21 or 24 words, got %d", len(words))
+		}
+
+		// Fix unknown words first, the checksum can't be checked until all words are known
+		allKnown := true
+		for i, word := range words {
+			if wordIndex[word] {
+				continue
+			}
+			allKnown = false
+			suggestions := SuggestWords(word, wordlist)
+			fmt.Printf("\nWord %d %q is not in the wordlist.\n", i+1, word)
+			replacement, err := askReplacement(reader, i+1, suggestions)
+			if err != nil {
+				return "", err
+			}
+			words[i] = replacement
+		}
+		if !allKnown {
+			continue
+		}
+
+		phrase := strings.Join(words, " ")
+		_, err := MnemonicToEntropy(phrase, wordlist)
+		if err == nil {
+			return phrase, nil
+		}
+		if err != ErrMnemonicChecksum {
+			return "", err
+		}
+
+		// All words are valid but one of them is the wrong word
+		fmt.Println("\nAll words are in the wordlist but the checksum is invalid, one or more words are wrong.")
+		for i, word := range words {
+			fmt.Printf("  %2d. %s\n", i+1, word)
+		}
+		fmt.Print("Enter the number of the word to change (Press Enter to abort): ")
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return "", err
+		}
+		line = strings.TrimSpace(line)
+		if line == "" {
+			return "", ErrMnemonicChecksum
+		}
+		position, err := strconv.Atoi(line)
+		if err != nil || position < 1 || position > len(words) {
+			fmt.Println("Invalid word number")
+			continue
+		}
+		replacement, err := askReplacement(reader, position, nil)
+		if err != nil {
+			return "", err
+		}
+		words[position-1] = replacement
+	}
+}
+
+// askReplacement prompts for a replacement word, either typed or picked from the suggestions
+func askReplacement(reader *bufio.Reader, position int, suggestions []string) (string, error) {
+	if len(suggestions) > 0 {
+		fmt.Println("Did you mean:")
+		for i, suggestion := range suggestions {
+			fmt.Printf("  %d) %s\n", i+1, suggestion)
+		}
+		fmt.Printf("Pick a suggestion or type word %d: ", position)
+	} else {
+		fmt.Printf("Type word %d: ", position)
+	}
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	line = strings.ToLower(strings.TrimSpace(norm.NFKD.String(line)))
+	if choice, err := strconv.Atoi(line); err == nil && choice >= 1 && choice <= len(suggestions) {
+		return suggestions[choice-1], nil
+	}
+	return line, nil
+}
@@ -0,0 +1,78 @@
+// phrasecheck_test.go
+package tbwallet
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestEditDistance(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"", "zoo", 3},
+		{"word", "word", 0},
+		{"wrd", "word", 1},
+		{"wordd", "word", 1},
+		{"wird", "word", 1},
+		{"owrd", "word", 2},
+		{"kitten", "sitting", 3},
+		{"ação", "acao", 2},
+	}
+	for _, test := range tests {
+		if got := editDistance(test.a, test.b); got != test.want {
+			t.Errorf("editDistance(%q, %q) = %d, want %d", test.a, test.b, got, test.want)
+		}
+		if got := editDistance(test.b, test.a); got != test.want {
+			t.Errorf("editDistance(%q, %q) = %d, want %d", test.b, test.a, got, test.want)
+		}
+	}
+}
+
+func TestSuggestWordsRanking(t *testing.T) {
+	wordlist := []string{"absent", "absorb", "abstract", "absurd", "bicycle", "cable", "table", "tablet"}
+	tests := []struct {
+		word string
+		want []string
+	}{
+		// A 4 letter prefix match ranks ahead of edit distance matches
+		{"abso", []string{"absorb"}},
+		{"tabl", []string{"table", "tablet", "cable"}},
+		{"cabls", []string{"cable", "table"}},
+		{"absurb", []string{"absurd", "absorb"}},
+		// Then the closest words, ties keep the wordlist order
+		{"tble", []string{"table", "cable", "tablet"}},
+		{"absrd", []string{"absurd", "absorb"}},
+		{"zzzz", nil},
+	}
+	for _, test := range tests {
+		if got := SuggestWords(test.word, wordlist); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("SuggestWords(%q) = %q, want %q", test.word, got, test.want)
+		}
+	}
+}
+
+func TestSuggestWordsEnglish(t *testing.T) {
+	wordlist, err := LoadWordlist()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		word string
+		want []string
+	}{
+		{"abandn", []string{"abandon"}},
+		{"abstrakt", []string{"abstract"}},
+		{"zoo", []string{"zoo", "book", "box", "boy", "cook"}},
+		{"acress", []string{"access", "across", "actress", "address", "arrest"}},
+		{"lettr", []string{"letter", "better", "later", "left"}},
+		{"xyzxyz", nil},
+	}
+	for _, test := range tests {
+		if got := SuggestWords(test.word, wordlist); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("SuggestWords(%q) = %q, want %q", test.word, got, test.want)
+		}
+	}
+}
@@ -6,14 +6,12 @@ import (
 	"encoding/hex"
 	"fmt"
 	"log"
-	"math/big"
 	"os"
 	"strings"
 
+	"tbwallet/keys"
 	"tbwallet/tbfunctions"
 
-	"github.com/btcsuite/btcd/btcec/v2"
-	"github.com/tyler-smith/go-bip32"
 	"golang.org/x/term"
 )
 
@@ -43,7 +41,30 @@ func RecoverWalletFromKey() {
 	}
 
 	hexPrivateKey = strings.TrimSpace(hexPrivateKey)
+	recoverFromHexKey(hexPrivateKey)
+}
+
+// RecoverWalletFromKeystore recovers a wallet from a Web3 Secret Storage (v3) JSON file
+func RecoverWalletFromKeystore(keystorePath string) {
+	keystore, err := tbfunctions.ReadKeystoreV3(keystorePath)
+	if err != nil {
+		log.Fatal("Error reading keystore:", err)
+	}
+
+	password, err := tbfunctions.ReadPassword("Enter keystore password: ")
+	if err != nil {
+		log.Fatal("Error reading password:", err)
+	}
+
+	privBytes, err := tbfunctions.DecryptKeystoreV3(keystore, password)
+	if err != nil {
+		log.Fatal("Error decrypting keystore:", err)
+	}
+	recoverFromHexKey(hex.EncodeToString(privBytes))
+}
 
+// recoverFromHexKey validates the private key, prints the wallet and saves it to the wallet path
+func recoverFromHexKey(hexPrivateKey string) {
 	// Validate hexadecimal format
 	if !tbfunctions.IsValidHex(hexPrivateKey) {
 		log.Fatal("Invalid input: Private key must be in hexadecimal format")
@@ -65,54 +86,45 @@ func RecoverWalletFromKey() {
 		log.Fatal("Private key must be at least 32 bytes long")
 	}
 
-	// Step 1: Hash the private key bytes to generate a valid private key
-	privateKeyD := new(big.Int).SetBytes(privBytes[:])
-
-	// Step 2: Generate the elliptic curve (P256 curve)
-	curve := btcec.S256()
-
-	// Step 3: Ensure the private key is valid (less than curve's order and greater than 0)
-	if privateKeyD.Cmp(curve.Params().N) >= 0 || privateKeyD.Sign() <= 0 {
+	// Step 1: Ensure the private key is valid (less than curve's order and greater than 0)
+	privateKey, err := keys.PrivateKeyFromBytes(privBytes)
+	if err != nil {
 		log.Fatal("Invalid private key: Key out of range")
 	}
 
-	// Step 4: Create the private key object
-	privateKey := &ecdsa.PrivateKey{
-		D: privateKeyD,
-		PublicKey: ecdsa.PublicKey{
-			Curve: curve,
-		},
-	}
-	// Step 5: Derive the corresponding public key (using scalar multiplication)
-	privateKey.PublicKey.X, privateKey.PublicKey.Y = curve.ScalarBaseMult(privateKey.D.Bytes())
-
-	// Step 6: Serialize the public key (compressed)
-	publicKey := SerializePublicKeyUncompressed(&privateKey.PublicKey)
+	// Step 2: Serialize the public key
+	publicKey := keys.PublicKeyBytes(&privateKey.PublicKey)
 	publicKeyHex := hex.EncodeToString(publicKey)
 
-	// Step 7: Load configuration
+	// Step 3: Load configuration
 	config, err := tbfunctions.LoadConfig()
 	if err != nil {
 		log.Fatal("Error loading config:", err)
 	}
 
-	// Step 8: Generate address based on the selected network (mainnet or testnet)
-	address, err := tbfunctions.GenerateAddress(publicKey)
-	if err != nil {
-		log.Fatal("Error generating address:", err)
-	}
+	// Step 4: Generate the address from the padded public key
+	address := keys.Address(&privateKey.PublicKey)
 
-	// Step 9: Print wallet information
+	// Step 5: Print wallet information
 	mnemonic := "RECOVERY PHRASE CAN'T BE RECOVERED BY ANY MEANS"
 	tbfunctions.PrintWallet(mnemonic, hexPrivateKey, publicKeyHex, address, "RECOVERED")
 
 	// Save wallet to system
 	walletFile := config.WalletPath
-	tbfunctions.SavePrivateKey(walletFile, hexPrivateKey)
+	err = tbfunctions.SavePrivateKey(walletFile, hexPrivateKey)
+	if err != nil {
+		fmt.Println("Error saving wallet:", err)
+	}
 }
 
 // RecoverWalletFromPhrase recovers a wallet using the mnemonic phrase
 func RecoverWalletFromPhrase() {
+	// Check the derivation path before asking for the phrase
+	path, err := DerivationPathFromFlags()
+	if err != nil {
+		log.Fatal("Error: ", err)
+	}
+
 	fmt.Print("Enter your recovery phrase (Press Enter twice to finish): ")
 
 	// Use bufio.NewReader to handle multi-line input
@@ -140,6 +152,19 @@ func RecoverWalletFromPhrase() {
 	// Join all mnemonic lines into a single string
 	mnemonic := strings.Join(mnemonicLines, " ")
 
+	// Phrases made before BIP-39 checksums were generated can only be recovered in legacy mode
+	if tbfunctions.HasFlag("--legacy") {
+		fmt.Println("Legacy mode: recovery phrase checksum is not verified")
+	} else {
+		reviewed, err := ReviewMnemonic(reader, mnemonic)
+		if err == ErrMnemonicChecksum {
+			log.Fatal("Error: ", err, `. Phrases created by older tbwallet versions have no checksum, recover them with "tbwallet recover -m --legacy"`)
+		} else if err != nil {
+			log.Fatal("Error: ", err)
+		}
+		mnemonic = reviewed
+	}
+
 	// Prompt for passphrase (optional)
 	fmt.Print("Enter your passphrase (Optional): ")
 	passphrase, err := term.ReadPassword(int(os.Stdin.Fd()))
@@ -150,7 +175,7 @@ func RecoverWalletFromPhrase() {
 	// Step 1: Derive the seed from mnemonic and passphrase
 	seed := DeriveSeedFromMnemonic(mnemonic, string(passphrase))
 	// Step 2: Derive private and public keys from the seed with derivation path
-	privateKey, compressedPublicKey, err := DeriveKeyPair(seed)
+	privateKey, compressedPublicKey, err := DeriveKeyPairWithPath(seed, path)
 	if err != nil {
 		log.Fatal("Error deriving key pair:", err)
 	}
@@ -162,58 +187,36 @@ func RecoverWalletFromPhrase() {
 	}
 
 	// Step 4: Generate the address using keccak256
-	address, err := tbfunctions.GenerateAddress(compressedPublicKey)
+	address, err := keys.AddressFromPublicKey(compressedPublicKey)
 	if err != nil {
 		log.Fatal("Error generating address:", err)
 	}
 
 	// Step 5: Print wallet information
-	privateKeyHex := hex.EncodeToString(privateKey.D.Bytes())
+	privateKeyHex := keys.PrivateKeyHex(privateKey)
 	publicKeyHex := hex.EncodeToString(compressedPublicKey)
 	tbfunctions.PrintWallet(mnemonic, privateKeyHex, publicKeyHex, address, "RECOVERED")
+	fmt.Println("Derivation path:", FormatDerivationPath(path))
 
-	// Save wallet to system
+	// Save wallet and seed to system
 	walletFile := config.WalletPath
-	tbfunctions.SavePrivateKey(walletFile, privateKeyHex)
-}
-
-// DeriveKeyPairWithPath derives a private and public key from the seed using BIP-44 path
-func DeriveKeyPairWithPath(seed []byte) (*ecdsa.PrivateKey, []byte, error) {
-	if len(seed) < 32 {
-		return nil, nil, fmt.Errorf("seed must be at least 32 bytes long")
-	}
-
-	// Use BIP-32 to derive keys from the seed
-	masterKey, err := bip32.NewMasterKey(seed)
+	err = SaveDerivedWallet(walletFile, privateKeyHex, seed, path)
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to generate master key: %v", err)
+		fmt.Println("Error saving wallet:", err)
 	}
+}
 
-	// BIP-44 path for Tulobyte: m/44'/202'/0'/0/0
-	tbtath := []uint32{44 + 0x80000000, 202 + 0x80000000, 0 + 0x80000000, 0, 0}
-	childKey := masterKey
-	for _, index := range tbtath {
-		childKey, err = childKey.NewChildKey(index)
-		if err != nil {
-			return nil, nil, fmt.Errorf("failed to derive keys: %v", err)
-		}
+// DeriveKeyPairWithPath derives a private and public key from the seed at a BIP-32 path
+func DeriveKeyPairWithPath(seed []byte, path []uint32) (*ecdsa.PrivateKey, []byte, error) {
+	childKey, err := deriveKey(seed, path)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	// Generate private key from the derived key
-	privateKeyD := new(big.Int).SetBytes(childKey.Key)
-
-	// Generate the public key using elliptic curve (P256)
-	curve := btcec.S256()
-	privateKey := &ecdsa.PrivateKey{
-		D: privateKeyD,
-		PublicKey: ecdsa.PublicKey{
-			Curve: curve,
-		},
+	privateKey, err := keys.PrivateKeyFromBytes(childKey.Key)
+	if err != nil {
+		return nil, nil, err
 	}
-	privateKey.PublicKey.X, privateKey.PublicKey.Y = curve.ScalarBaseMult(privateKey.D.Bytes())
-
-	// Serialize the public key (compressed)
-	uncompressedPublicKey := SerializePublicKeyCompressed(&privateKey.PublicKey)
-
+	uncompressedPublicKey := keys.PublicKeyBytes(&privateKey.PublicKey)
 	return privateKey, uncompressedPublicKey, nil
 }
@@ -0,0 +1,476 @@
+// slip39.go
+package tbwallet
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+// SLIP-39 constants, see https://github.com/satoshilabs/slips/blob/master/slip-0039.md
+const (
+	slip39RadixBits          = 10
+	slip39ChecksumWords      = 3
+	slip39MetadataWords      = 4 // identifier, extendable flag, iteration exponent and share parameters
+	slip39MinSecretBytes     = 16
+	slip39MaxShareCount      = 16
+	slip39BaseIterations     = 10000
+	slip39Rounds             = 4
+	slip39DigestIndex        = 254
+	slip39SecretIndex        = 255
+	slip39DigestLength       = 4
+	slip39CustomString       = "shamir"
+	slip39CustomStringExtend = "shamir_extendable"
+)
+
+// ErrSlip39NotEnoughShares is returned by CombineSlip39 while more shares are needed
+var ErrSlip39NotEnoughShares = errors.New("not enough shares to recover the secret")
+
+// Slip39Share is a decoded SLIP-39 mnemonic share
+type Slip39Share struct {
+	Identifier        uint16
+	Extendable        bool
+	IterationExponent byte
+	GroupIndex        byte
+	GroupThreshold    byte
+	GroupCount        byte
+	MemberIndex       byte
+	MemberThreshold   byte
+	Value             []byte
+}
+
+type rawShare struct {
+	x     byte
+	value []byte
+}
+
+// SplitSlip39 splits a master secret into shares of a single group, any
+// threshold of which recover the secret. The secret is encrypted with the
+// passphrase before it is split.
+func SplitSlip39(masterSecret []byte, passphrase []byte, threshold int, shareCount int, iterationExponent byte) ([]string, error) {
+	if len(masterSecret) < slip39MinSecretBytes || len(masterSecret)%2 != 0 {
+		return nil, fmt.Errorf("master secret must be at least %d bytes and an even length", slip39MinSecretBytes)
+	}
+	if shareCount < 1 || shareCount > slip39MaxShareCount {
+		return nil, fmt.Errorf("share count must be between 1 and %d", slip39MaxShareCount)
+	}
+	if threshold < 1 || threshold > shareCount {
+		return nil, errors.New("threshold must be between 1 and the share count")
+	}
+	if threshold == 1 && shareCount > 1 {
+		return nil, errors.New("a threshold of 1 with more than one share is not allowed, use a threshold of at least 2")
+	}
+	if iterationExponent > 15 {
+		return nil, errors.New("iteration exponent must be between 0 and 15")
+	}
+
+	idBytes := make([]byte, 2)
+	if _, err := rand.Read(idBytes); err != nil {
+		return nil, err
+	}
+	identifier := binary.BigEndian.Uint16(idBytes) & 0x7fff
+
+	encryptedSecret := slip39Crypt(masterSecret, passphrase, iterationExponent, identifier, false, true)
+
+	// One group with a group threshold of 1 holds the encrypted secret as is
+	memberShares, err := splitSecret(threshold, shareCount, encryptedSecret)
+	if err != nil {
+		return nil, err
+	}
+
+	var mnemonics []string
+	for _, member := range memberShares {
+		share := Slip39Share{
+			Identifier:        identifier,
+			IterationExponent: iterationExponent,
+			GroupIndex:        0,
+			GroupThreshold:    1,
+			GroupCount:        1,
+			MemberIndex:       member.x,
+			MemberThreshold:   byte(threshold),
+			Value:             member.value,
+		}
+		mnemonics = append(mnemonics, EncodeSlip39Share(share))
+	}
+	return mnemonics, nil
+}
+
+// CombineSlip39 recovers the master secret from SLIP-39 mnemonic shares
+func CombineSlip39(mnemonics []string, passphrase []byte) ([]byte, error) {
+	if len(mnemonics) == 0 {
+		return nil, ErrSlip39NotEnoughShares
+	}
+
+	var shares []Slip39Share
+	for _, mnemonic := range mnemonics {
+		share, err := DecodeSlip39Share(mnemonic)
+		if err != nil {
+			return nil, err
+		}
+		shares = append(shares, share)
+	}
+
+	first := shares[0]
+	groups := map[byte][]Slip39Share{}
+	for _, share := range shares {
+		if share.Identifier != first.Identifier || share.Extendable != first.Extendable ||
+			share.IterationExponent != first.IterationExponent {
+			return nil, errors.New("shares do not belong to the same backup")
+		}
+		if share.GroupThreshold != first.GroupThreshold || share.GroupCount != first.GroupCount {
+			return nil, errors.New("shares have mismatching group parameters")
+		}
+		if len(share.Value) != len(first.Value) {
+			return nil, errors.New("shares have different lengths")
+		}
+		for _, other := range groups[share.GroupIndex] {
+			if other.MemberIndex == share.MemberIndex {
+				if !bytes.Equal(other.Value, share.Value) {
+					return nil, errors.New("two different shares have the same member index")
+				}
+			}
+			if other.MemberThreshold != share.MemberThreshold {
+				return nil, errors.New("shares of a group have mismatching member thresholds")
+			}
+		}
+		groups[share.GroupIndex] = appendUniqueShare(groups[share.GroupIndex], share)
+	}
+
+	var groupShares []rawShare
+	for groupIndex, members := range groups {
+		if len(members) < int(members[0].MemberThreshold) {
+			continue
+		}
+		var memberShares []rawShare
+		for _, member := range members[:members[0].MemberThreshold] {
+			memberShares = append(memberShares, rawShare{member.MemberIndex, member.Value})
+		}
+		groupSecret, err := recoverSecret(int(members[0].MemberThreshold), memberShares)
+		if err != nil {
+			return nil, err
+		}
+		groupShares = append(groupShares, rawShare{groupIndex, groupSecret})
+	}
+	if len(groupShares) < int(first.GroupThreshold) {
+		return nil, ErrSlip39NotEnoughShares
+	}
+
+	encryptedSecret, err := recoverSecret(int(first.GroupThreshold), groupShares[:first.GroupThreshold])
+	if err != nil {
+		return nil, err
+	}
+	return slip39Crypt(encryptedSecret, passphrase, first.IterationExponent, first.Identifier, first.Extendable, false), nil
+}
+
+// Slip39SharesNeeded reports how many more shares are needed for the first
+// group of the given shares to reach its member threshold
+func Slip39SharesNeeded(share Slip39Share, have int) int {
+	needed := int(share.MemberThreshold) - have
+	if needed < 0 {
+		return 0
+	}
+	return needed
+}
+
+func appendUniqueShare(shares []Slip39Share, share Slip39Share) []Slip39Share {
+	for _, other := range shares {
+		if other.MemberIndex == share.MemberIndex {
+			return shares
+		}
+	}
+	return append(shares, share)
+}
+
+// EncodeSlip39Share encodes a share as a mnemonic with its RS1024 checksum
+func EncodeSlip39Share(share Slip39Share) string {
+	extendable := 0
+	if share.Extendable {
+		extendable = 1
+	}
+	idExp := int(share.Identifier)<<5 | extendable<<4 | int(share.IterationExponent)
+	params := int(share.GroupIndex)<<16 | int(share.GroupThreshold-1)<<12 | int(share.GroupCount-1)<<8 |
+		int(share.MemberIndex)<<4 | int(share.MemberThreshold-1)
+
+	values := []int{idExp >> 10, idExp & 1023, params >> 10, params & 1023}
+
+	// The share value is left padded with zero bits to a multiple of 10 bits
+	valueWords := (len(share.Value)*8 + slip39RadixBits - 1) / slip39RadixBits
+	valueInt := new(big.Int).SetBytes(share.Value)
+	mask := big.NewInt(1023)
+	for i := valueWords - 1; i >= 0; i-- {
+		word := new(big.Int).Rsh(valueInt, uint(i*slip39RadixBits))
+		values = append(values, int(word.And(word, mask).Int64()))
+	}
+
+	values = append(values, rs1024CreateChecksum(customizationString(share.Extendable), values)...)
+
+	words := make([]string, len(values))
+	for i, value := range values {
+		words[i] = slip39Wordlist[value]
+	}
+	return strings.Join(words, " ")
+}
+
+// DecodeSlip39Share parses a mnemonic share and verifies its checksum
+func DecodeSlip39Share(mnemonic string) (Slip39Share, error) {
+	var share Slip39Share
+
+	wordIndex := make(map[string]int, len(slip39Wordlist))
+	for i, word := range slip39Wordlist {
+		wordIndex[word] = i
+	}
+	words := strings.Fields(strings.ToLower(mnemonic))
+	values := make([]int, len(words))
+	for i, word := range words {
+		index, ok := wordIndex[word]
+		if !ok {
+			return share, fmt.Errorf("word %d %q is not in the SLIP-39 wordlist", i+1, word)
+		}
+		values[i] = index
+	}
+
+	minWords := slip39MetadataWords + slip39ChecksumWords + (slip39MinSecretBytes*8+slip39RadixBits-1)/slip39RadixBits
+	if len(values) < minWords {
+		return share, fmt.Errorf("share must have at least %d words, got %d", minWords, len(values))
+	}
+	paddingBits := (slip39RadixBits * (len(values) - slip39MetadataWords - slip39ChecksumWords)) % 16
+	if paddingBits > 8 {
+		return share, errors.New("share has an invalid length")
+	}
+
+	idExp := values[0]<<10 | values[1]
+	share.Identifier = uint16(idExp >> 5)
+	share.Extendable = (idExp>>4)&1 == 1
+	share.IterationExponent = byte(idExp & 15)
+
+	if !rs1024VerifyChecksum(customizationString(share.Extendable), values) {
+		return share, errors.New("share checksum is invalid, check the words for typos")
+	}
+
+	params := values[2]<<10 | values[3]
+	share.GroupIndex = byte(params >> 16)
+	share.GroupThreshold = byte((params>>12)&15) + 1
+	share.GroupCount = byte((params>>8)&15) + 1
+	share.MemberIndex = byte((params >> 4) & 15)
+	share.MemberThreshold = byte(params&15) + 1
+	if share.GroupThreshold > share.GroupCount {
+		return share, errors.New("share group threshold exceeds the group count")
+	}
+
+	valueWords := values[slip39MetadataWords : len(values)-slip39ChecksumWords]
+	valueInt := new(big.Int)
+	for _, value := range valueWords {
+		valueInt.Lsh(valueInt, slip39RadixBits)
+		valueInt.Or(valueInt, big.NewInt(int64(value)))
+	}
+	valueBytes := (len(valueWords)*slip39RadixBits - paddingBits) / 8
+	if valueInt.BitLen() > valueBytes*8 {
+		return share, errors.New("share has invalid padding")
+	}
+	share.Value = make([]byte, valueBytes)
+	valueInt.FillBytes(share.Value)
+	return share, nil
+}
+
+func customizationString(extendable bool) string {
+	if extendable {
+		return slip39CustomStringExtend
+	}
+	return slip39CustomString
+}
+
+// rs1024Polymod is the Reed-Solomon checksum over GF(1024) used by SLIP-39
+func rs1024Polymod(values []int) int {
+	generator := []int{
+		0xE0E040, 0x1C1C080, 0x3838100, 0x7070200, 0xE0E0009,
+		0x1C0C2412, 0x38086C24, 0x3090FC48, 0x21B1F890, 0x3F3F120,
+	}
+	checksum := 1
+	for _, value := range values {
+		top := checksum >> 20
+		checksum = (checksum&0xFFFFF)<<10 ^ value
+		for i := 0; i < 10; i++ {
+			if (top>>i)&1 == 1 {
+				checksum ^= generator[i]
+			}
+		}
+	}
+	return checksum
+}
+
+func rs1024CreateChecksum(customization string, values []int) []int {
+	var data []int
+	for _, c := range []byte(customization) {
+		data = append(data, int(c))
+	}
+	data = append(data, values...)
+	data = append(data, 0, 0, 0)
+	polymod := rs1024Polymod(data) ^ 1
+	return []int{(polymod >> 20) & 1023, (polymod >> 10) & 1023, polymod & 1023}
+}
+
+func rs1024VerifyChecksum(customization string, values []int) bool {
+	var data []int
+	for _, c := range []byte(customization) {
+		data = append(data, int(c))
+	}
+	data = append(data, values...)
+	return rs1024Polymod(data) == 1
+}
+
+// slip39Crypt runs the 4 round Feistel network that encrypts or decrypts the master secret
+func slip39Crypt(secret []byte, passphrase []byte, iterationExponent byte, identifier uint16, extendable bool, encrypt bool) []byte {
+	half := len(secret) / 2
+	left := append([]byte{}, secret[:half]...)
+	right := append([]byte{}, secret[half:]...)
+
+	var salt []byte
+	if !extendable {
+		salt = append([]byte(slip39CustomString), byte(identifier>>8), byte(identifier))
+	}
+	iterations := (slip39BaseIterations << iterationExponent) / slip39Rounds
+
+	for round := 0; round < slip39Rounds; round++ {
+		i := byte(round)
+		if !encrypt {
+			i = byte(slip39Rounds - 1 - round)
+		}
+		password := append([]byte{i}, passphrase...)
+		roundSalt := append(append([]byte{}, salt...), right...)
+		f := pbkdf2.Key(password, roundSalt, iterations, half, sha256.New)
+		for j := range left {
+			left[j] ^= f[j]
+		}
+		left, right = right, left
+	}
+	return append(right, left...)
+}
+
+// GF(256) exp and log tables for the AES polynomial x^8 + x^4 + x^3 + x + 1
+var gfExp, gfLog = gfTables()
+
+func gfTables() ([255]byte, [256]byte) {
+	var exp [255]byte
+	var log [256]byte
+	poly := 1
+	for i := 0; i < 255; i++ {
+		exp[i] = byte(poly)
+		log[poly] = byte(i)
+		// Multiply by the generator 3
+		poly = (poly << 1) ^ poly
+		if poly&0x100 != 0 {
+			poly ^= 0x11B
+		}
+	}
+	return exp, log
+}
+
+// interpolate evaluates at x the polynomial running through the given shares
+func interpolate(shares []rawShare, x byte) ([]byte, error) {
+	for _, share := range shares {
+		if share.x == x {
+			return share.value, nil
+		}
+	}
+
+	length := len(shares[0].value)
+	logProduct := 0
+	for _, share := range shares {
+		logProduct += int(gfLog[share.x^x])
+	}
+
+	result := make([]byte, length)
+	for _, share := range shares {
+		if len(share.value) != length {
+			return nil, errors.New("shares have different lengths")
+		}
+		logBasis := logProduct - int(gfLog[share.x^x])
+		for _, other := range shares {
+			if other.x == share.x {
+				continue
+			}
+			logBasis -= int(gfLog[share.x^other.x])
+		}
+		logBasis = ((logBasis % 255) + 255) % 255
+
+		for i, value := range share.value {
+			if value != 0 {
+				result[i] ^= gfExp[(int(gfLog[value])+logBasis)%255]
+			}
+		}
+	}
+	return result, nil
+}
+
+func shareDigest(randomPart []byte, secret []byte) []byte {
+	mac := hmac.New(sha256.New, randomPart)
+	mac.Write(secret)
+	return mac.Sum(nil)[:slip39DigestLength]
+}
+
+// splitSecret is Shamir's secret sharing with a digest share at x = 254
+func splitSecret(threshold int, shareCount int, secret []byte) ([]rawShare, error) {
+	if threshold == 1 {
+		var shares []rawShare
+		for i := 0; i < shareCount; i++ {
+			shares = append(shares, rawShare{byte(i), append([]byte{}, secret...)})
+		}
+		return shares, nil
+	}
+
+	randomShareCount := threshold - 2
+	var shares []rawShare
+	for i := 0; i < randomShareCount; i++ {
+		value := make([]byte, len(secret))
+		if _, err := rand.Read(value); err != nil {
+			return nil, err
+		}
+		shares = append(shares, rawShare{byte(i), value})
+	}
+
+	randomPart := make([]byte, len(secret)-slip39DigestLength)
+	if _, err := rand.Read(randomPart); err != nil {
+		return nil, err
+	}
+	digest := append(shareDigest(randomPart, secret), randomPart...)
+
+	baseShares := append(append([]rawShare{}, shares...),
+		rawShare{slip39DigestIndex, digest},
+		rawShare{slip39SecretIndex, secret},
+	)
+	for i := randomShareCount; i < shareCount; i++ {
+		value, err := interpolate(baseShares, byte(i))
+		if err != nil {
+			return nil, err
+		}
+		shares = append(shares, rawShare{byte(i), value})
+	}
+	return shares, nil
+}
+
+// recoverSecret interpolates the secret and checks it against the digest share
+func recoverSecret(threshold int, shares []rawShare) ([]byte, error) {
+	if threshold == 1 {
+		return shares[0].value, nil
+	}
+	secret, err := interpolate(shares, slip39SecretIndex)
+	if err != nil {
+		return nil, err
+	}
+	digestShare, err := interpolate(shares, slip39DigestIndex)
+	if err != nil {
+		return nil, err
+	}
+	if !hmac.Equal(digestShare[:slip39DigestLength], shareDigest(digestShare[slip39DigestLength:], secret)) {
+		return nil, errors.New("share digest does not match, the shares are invalid")
+	}
+	return secret, nil
+}
@@ -0,0 +1,229 @@
+// slip39_test.go
+package tbwallet
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// Vectors of the SLIP-39 specification, https://github.com/trezor/python-shamir-mnemonic/blob/master/vectors.json
+var slip39Vectors = []struct {
+	name         string
+	mnemonics    []string
+	masterSecret string
+}{
+	{
+		"valid mnemonic without sharing (128 bits)",
+		[]string{"duckling enlarge academic academic agency result length solution fridge kidney coal piece deal husband erode duke ajar critical decision keyboard"},
+		"bb54aac4b89dc868ba37d9cc21b2cece",
+	},
+	{
+		"basic sharing 2-of-3 (128 bits)",
+		[]string{
+			"shadow pistol academic always adequate wildlife fancy gross oasis cylinder mustang wrist rescue view short owner flip making coding armed",
+			"shadow pistol academic acid actress prayer class unknown daughter sweater depict flip twice unkind craft early superior advocate guest smoking",
+		},
+		"b43ceb7e57a0ea8766221624d01b0864",
+	},
+	{
+		"valid mnemonic without sharing (256 bits)",
+		[]string{"theory painting academic academic armed sweater year military elder discuss acne wildlife boring employer fused large satoshi bundle carbon diagnose anatomy hamster leaves tracks paces beyond phantom capital marvel lips brave detect luck"},
+		"989baf9dcaad5b10ca33dfd8cc75e42477025dce88ae83e75a230086a0e00e92",
+	},
+	{
+		"valid extendable mnemonic without sharing (128 bits)",
+		[]string{"testify swimming academic academic column loyalty smear include exotic bedroom exotic wrist lobe cover grief golden smart junior estimate learn"},
+		"1679b4516e0ee5954351d288a838f45e",
+	},
+}
+
+// The vectors are encrypted with this passphrase
+const slip39VectorPassphrase = "TREZOR"
+
+func TestSlip39Vectors(t *testing.T) {
+	for _, vector := range slip39Vectors {
+		masterSecret, err := CombineSlip39(vector.mnemonics, []byte(slip39VectorPassphrase))
+		if err != nil {
+			t.Errorf("%s: %v", vector.name, err)
+			continue
+		}
+		if got := hex.EncodeToString(masterSecret); got != vector.masterSecret {
+			t.Errorf("%s: master secret = %s, want %s", vector.name, got, vector.masterSecret)
+		}
+		// Shares encode back to the same words
+		for _, mnemonic := range vector.mnemonics {
+			share, err := DecodeSlip39Share(mnemonic)
+			if err != nil {
+				t.Fatalf("%s: %v", vector.name, err)
+			}
+			if encoded := EncodeSlip39Share(share); encoded != mnemonic {
+				t.Errorf("%s: EncodeSlip39Share = %s, want %s", vector.name, encoded, mnemonic)
+			}
+		}
+	}
+}
+
+func TestSlip39InvalidVectors(t *testing.T) {
+	tests := []struct {
+		name      string
+		mnemonics []string
+		want      string
+	}{
+		{
+			"invalid checksum",
+			[]string{"duckling enlarge academic academic agency result length solution fridge kidney coal piece deal husband erode duke ajar critical decision kidney"},
+			"checksum",
+		},
+		{
+			"invalid padding",
+			[]string{"duckling enlarge academic academic email result length solution fridge kidney coal piece deal husband erode duke ajar music cargo fitness"},
+			"padding",
+		},
+		{
+			"shares of different backups",
+			[]string{slip39Vectors[1].mnemonics[0], slip39Vectors[0].mnemonics[0]},
+			"same backup",
+		},
+	}
+	for _, test := range tests {
+		_, err := CombineSlip39(test.mnemonics, []byte(slip39VectorPassphrase))
+		if err == nil || !strings.Contains(err.Error(), test.want) {
+			t.Errorf("%s: error = %v, want %q", test.name, err, test.want)
+		}
+	}
+
+	// One share of the 2-of-3 vector isn't enough
+	_, err := CombineSlip39(slip39Vectors[1].mnemonics[:1], []byte(slip39VectorPassphrase))
+	if !errors.Is(err, ErrSlip39NotEnoughShares) {
+		t.Errorf("1 of 2 shares: error = %v, want ErrSlip39NotEnoughShares", err)
+	}
+}
+
+// combinations returns every subset of size k of 0..n-1
+func combinations(n int, k int) [][]int {
+	if k == 0 {
+		return [][]int{nil}
+	}
+	var subsets [][]int
+	for first := 0; first <= n-k; first++ {
+		for _, rest := range combinations(n-first-1, k-1) {
+			subset := []int{first}
+			for _, index := range rest {
+				subset = append(subset, first+1+index)
+			}
+			subsets = append(subsets, subset)
+		}
+	}
+	return subsets
+}
+
+func TestSlip39SplitCombine(t *testing.T) {
+	masterSecret, _ := hex.DecodeString("0c94e6b6c3e7b3ac7b8f2b1a4d2e3f40")
+	passphrase := []byte("correct horse")
+	tests := []struct{ threshold, shareCount int }{{1, 1}, {2, 3}, {3, 5}}
+	for _, test := range tests {
+		mnemonics, err := SplitSlip39(masterSecret, passphrase, test.threshold, test.shareCount, 0)
+		if err != nil {
+			t.Fatalf("%d-of-%d: %v", test.threshold, test.shareCount, err)
+		}
+		if len(mnemonics) != test.shareCount {
+			t.Fatalf("%d-of-%d: %d shares", test.threshold, test.shareCount, len(mnemonics))
+		}
+
+		// Any threshold shares recover the secret
+		for _, subset := range combinations(test.shareCount, test.threshold) {
+			var chosen []string
+			for _, index := range subset {
+				chosen = append(chosen, mnemonics[index])
+			}
+			got, err := CombineSlip39(chosen, passphrase)
+			if err != nil || !bytes.Equal(got, masterSecret) {
+				t.Errorf("%d-of-%d shares %v: %x, %v", test.threshold, test.shareCount, subset, got, err)
+			}
+		}
+
+		// One share less than the threshold doesn't
+		if test.threshold > 1 {
+			_, err := CombineSlip39(mnemonics[:test.threshold-1], passphrase)
+			if !errors.Is(err, ErrSlip39NotEnoughShares) {
+				t.Errorf("%d-of-%d with %d shares: error = %v, want ErrSlip39NotEnoughShares",
+					test.threshold, test.shareCount, test.threshold-1, err)
+			}
+			// A repeated share counts once
+			repeated := []string{mnemonics[0]}
+			for i := 1; i < test.threshold; i++ {
+				repeated = append(repeated, mnemonics[0])
+			}
+			if _, err := CombineSlip39(repeated, passphrase); !errors.Is(err, ErrSlip39NotEnoughShares) {
+				t.Errorf("%d-of-%d with a repeated share: error = %v, want ErrSlip39NotEnoughShares", test.threshold, test.shareCount, err)
+			}
+		}
+	}
+
+	// The passphrase isn't checked, another one gives another secret
+	mnemonics, err := SplitSlip39(masterSecret, passphrase, 2, 3, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := CombineSlip39(mnemonics[:2], []byte("wrong"))
+	if err != nil || bytes.Equal(got, masterSecret) {
+		t.Errorf("wrong passphrase: %x, %v", got, err)
+	}
+}
+
+func TestSlip39CorruptedShare(t *testing.T) {
+	masterSecret, _ := hex.DecodeString("0c94e6b6c3e7b3ac7b8f2b1a4d2e3f40")
+	mnemonics, err := SplitSlip39(masterSecret, nil, 2, 3, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	words := strings.Fields(mnemonics[1])
+	// A value word and a checksum word
+	for _, index := range []int{5, len(words) - 1} {
+		corrupted := append([]string(nil), words...)
+		// Swap the word for its neighbour in the wordlist
+		for position, candidate := range slip39Wordlist {
+			if candidate == words[index] {
+				corrupted[index] = slip39Wordlist[(position+1)%len(slip39Wordlist)]
+				break
+			}
+		}
+		_, err := CombineSlip39([]string{mnemonics[0], strings.Join(corrupted, " ")}, nil)
+		if err == nil || !strings.Contains(err.Error(), "checksum") {
+			t.Errorf("word %d corrupted: error = %v, want a checksum error", index+1, err)
+		}
+	}
+
+	if _, err := DecodeSlip39Share("duckling enlarge academic academic agency result length solution fridge kidney coal piece deal husband erode duke ajar critical decision notaword"); err == nil {
+		t.Error("a word outside the wordlist was accepted")
+	}
+	if _, err := DecodeSlip39Share("duckling enlarge academic academic agency"); err == nil {
+		t.Error("a short share was accepted")
+	}
+}
+
+func TestSplitSlip39Errors(t *testing.T) {
+	secret := make([]byte, 16)
+	tests := []struct {
+		name              string
+		secret            []byte
+		threshold, shares int
+		exponent          byte
+	}{
+		{"short secret", make([]byte, 14), 2, 3, 0},
+		{"odd secret", make([]byte, 17), 2, 3, 0},
+		{"no shares", secret, 1, 0, 0},
+		{"too many shares", secret, 2, 17, 0},
+		{"threshold above count", secret, 4, 3, 0},
+		{"threshold of 1", secret, 1, 3, 0},
+		{"iteration exponent", secret, 2, 3, 16},
+	}
+	for _, test := range tests {
+		if _, err := SplitSlip39(test.secret, nil, test.threshold, test.shares, test.exponent); err == nil {
+			t.Errorf("%s: no error", test.name)
+		}
+	}
+}
@@ -0,0 +1,227 @@
+// vanity.go
+package tbwallet
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"tbwallet/keys"
+	"tbwallet/tbfunctions"
+)
+
+// How often the vanity search prints its progress
+const vanityProgressInterval = 2 * time.Second
+
+// vanityPattern is the prefix and suffix an address must have, without the 0x
+type vanityPattern struct {
+	prefix        string
+	suffix        string
+	caseSensitive bool
+}
+
+// parseVanityPattern checks that the pattern is hex and fits in an address.
+// Case insensitive patterns are lower cased to match keys.Address output.
+func parseVanityPattern(prefix string, suffix string, caseSensitive bool) (vanityPattern, error) {
+	prefix = strings.TrimPrefix(prefix, "0x")
+	if len(prefix)+len(suffix) == 0 {
+		return vanityPattern{}, fmt.Errorf("give a --prefix, a --suffix or both")
+	}
+	if len(prefix)+len(suffix) > 40 {
+		return vanityPattern{}, fmt.Errorf("prefix and suffix are longer than an address")
+	}
+	if !tbfunctions.IsValidHex(prefix) || !tbfunctions.IsValidHex(suffix) {
+		return vanityPattern{}, fmt.Errorf("prefix and suffix may only contain hex characters 0-9 and a-f")
+	}
+	if !caseSensitive {
+		prefix, suffix = strings.ToLower(prefix), strings.ToLower(suffix)
+	}
+	return vanityPattern{prefix: prefix, suffix: suffix, caseSensitive: caseSensitive}, nil
+}
+
+// matches reports whether an address from keys.Address has the pattern
+func (pattern vanityPattern) matches(address string) bool {
+	if pattern.caseSensitive {
+		address = keys.ChecksumAddress(address)
+	}
+	address = address[2:]
+	return strings.HasPrefix(address, pattern.prefix) && strings.HasSuffix(address, pattern.suffix)
+}
+
+// difficulty is the expected number of keys to try, 16 per hex character and
+// twice as many for every letter that must have the right checksum case
+func (pattern vanityPattern) difficulty() float64 {
+	characters := pattern.prefix + pattern.suffix
+	difficulty := math.Pow(16, float64(len(characters)))
+	if pattern.caseSensitive {
+		letters := 0
+		for _, c := range characters {
+			if (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F') {
+				letters++
+			}
+		}
+		difficulty *= math.Pow(2, float64(letters))
+	}
+	return difficulty
+}
+
+// vanityResult is the key that produced a matching address
+type vanityResult struct {
+	privateKey []byte
+	publicKey  []byte
+	address    string
+}
+
+// searchVanity tries random keys on every worker until one matches pattern.
+// attempts counts the keys tried by all workers.
+func searchVanity(pattern vanityPattern, workers int, attempts *atomic.Uint64) (vanityResult, error) {
+	found := make(chan vanityResult, 1)
+	failed := make(chan error, 1)
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			privateKeyBytes := make([]byte, 32)
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+
+				if _, err := rand.Read(privateKeyBytes); err != nil {
+					select {
+					case failed <- err:
+					default:
+					}
+					return
+				}
+				privateKey, err := keys.PrivateKeyFromBytes(privateKeyBytes)
+				if err != nil {
+					// Out of range for secp256k1, try the next one
+					continue
+				}
+				attempts.Add(1)
+				address := keys.Address(&privateKey.PublicKey)
+				if !pattern.matches(address) {
+					continue
+				}
+				select {
+				case found <- vanityResult{privateKey: append([]byte(nil), privateKeyBytes...), publicKey: keys.PublicKeyBytes(&privateKey.PublicKey), address: address}:
+				default:
+				}
+				return
+			}
+		}()
+	}
+
+	var result vanityResult
+	var err error
+	select {
+	case result = <-found:
+	case err = <-failed:
+	}
+	close(done)
+	wg.Wait()
+	return result, err
+}
+
+// formatDuration prints a rough duration for the progress line
+func formatDuration(seconds float64) string {
+	switch {
+	case math.IsInf(seconds, 0) || math.IsNaN(seconds):
+		return "unknown"
+	case seconds < 60:
+		return fmt.Sprintf("%.0fs", seconds)
+	case seconds < 3600:
+		return fmt.Sprintf("%.0fm", seconds/60)
+	case seconds < 86400:
+		return fmt.Sprintf("%.1fh", seconds/3600)
+	case seconds < 365*86400:
+		return fmt.Sprintf("%.1f days", seconds/86400)
+	default:
+		return fmt.Sprintf("%.1f years", seconds/(365*86400))
+	}
+}
+
+// GenerateVanityWallet brute forces keys until the address has the requested
+// prefix and suffix, then saves the winning key as the wallet
+func GenerateVanityWallet() {
+	prefix, _ := tbfunctions.FlagValue("--prefix")
+	suffix, _ := tbfunctions.FlagValue("--suffix")
+	pattern, err := parseVanityPattern(prefix, suffix, tbfunctions.HasFlag("--case-sensitive"))
+	if err != nil {
+		fmt.Println("Error:", err)
+		tbfunctions.PrintVanityHelp()
+		return
+	}
+
+	workers := runtime.NumCPU()
+	if value, isGiven := tbfunctions.FlagValue("--workers"); isGiven {
+		workers, err = strconv.Atoi(value)
+		if err != nil || workers < 1 {
+			fmt.Println("Error: invalid worker count:", value)
+			return
+		}
+	}
+
+	difficulty := pattern.difficulty()
+	fmt.Printf("Searching for 0x%s...%s on %d workers, about %.0f keys per match\n", pattern.prefix, pattern.suffix, workers, difficulty)
+
+	// Print the key rate and the time to a 50% chance of a match until the search ends
+	var attempts atomic.Uint64
+	start := time.Now()
+	stopProgress := make(chan struct{})
+	progressDone := make(chan struct{})
+	go func() {
+		defer close(progressDone)
+		ticker := time.NewTicker(vanityProgressInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stopProgress:
+				return
+			case <-ticker.C:
+				tried := float64(attempts.Load())
+				rate := tried / time.Since(start).Seconds()
+				// P(match within n keys) = 1 - (1 - 1/difficulty)^n, so 50% takes ln(2) * difficulty keys
+				remaining := math.Max(math.Ln2*difficulty-tried, 0) / rate
+				fmt.Printf("\rTried %.0f keys, %.0f keys/s, 50%% chance within %s    ", tried, rate, formatDuration(remaining))
+			}
+		}
+	}()
+
+	result, err := searchVanity(pattern, workers, &attempts)
+	close(stopProgress)
+	<-progressDone
+	fmt.Println()
+	if err != nil {
+		fmt.Println("Error generating keys:", err)
+		return
+	}
+	fmt.Printf("Found after %d keys in %s\n", attempts.Load(), time.Since(start).Round(time.Second))
+
+	config, err := tbfunctions.LoadConfig()
+	if err != nil {
+		fmt.Println("Error loading config:", err)
+		return
+	}
+
+	privateKeyHex := hex.EncodeToString(result.privateKey)
+	tbfunctions.PrintWallet("", privateKeyHex, hex.EncodeToString(result.publicKey), result.address, "GENERATED")
+
+	err = tbfunctions.SavePrivateKey(config.WalletPath, privateKeyHex)
+	if err != nil {
+		fmt.Println("Error saving wallet:", err)
+	}
+}
@@ -0,0 +1,87 @@
+// watchonly.go
+package tbwallet
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"tbwallet/tbfunctions"
+
+	"github.com/tyler-smith/go-bip32"
+)
+
+// IsWatchOnly reports whether the configured wallet is a watch-only wallet
+func IsWatchOnly() bool {
+	wallet, _, err := loadWalletFile()
+	return err == nil && wallet.WatchOnly
+}
+
+// checkAccountXPub checks that xpub is a public account level key for the
+// account of path, a key from another level derives unrelated addresses
+func checkAccountXPub(xpub string, path []uint32) error {
+	key, err := bip32.B58Deserialize(xpub)
+	if err != nil {
+		return fmt.Errorf("invalid extended public key: %v", err)
+	}
+	if key.IsPrivate {
+		return errors.New("that is an extended private key, export the xpub with \"tbwallet export --xpub\"")
+	}
+	if key.Depth != 3 || binary.BigEndian.Uint32(key.ChildNumber) != path[2] {
+		return fmt.Errorf("extended public key is not the account key of %s", FormatDerivationPath(path))
+	}
+	return nil
+}
+
+// RecoverWatchOnly saves a watch-only wallet from an account xpub. The
+// --path or --preset path selects the account and the default address.
+func RecoverWatchOnly(xpub string) {
+	path, err := DerivationPathFromFlags()
+	if err != nil {
+		fmt.Println("Error:", err)
+		return
+	}
+	if !isAccountPath(path) {
+		fmt.Printf("Error: path %s has no BIP-44 account level\n", FormatDerivationPath(path))
+		return
+	}
+	err = checkAccountXPub(xpub, path)
+	if err != nil {
+		fmt.Println("Error:", err)
+		return
+	}
+
+	address, publicKey, err := AddressFromXPub(xpub, path[3], path[4])
+	if err != nil {
+		fmt.Println("Error:", err)
+		return
+	}
+	config, err := tbfunctions.LoadConfig()
+	if err != nil {
+		fmt.Println("Error loading config:", err)
+		return
+	}
+
+	account, index := pathAccountIndex(path)
+	wallet := tbfunctions.WalletFile{
+		Version:   tbfunctions.WalletFileVersion,
+		Address:   address,
+		PublicKey: hex.EncodeToString(publicKey),
+		WatchOnly: true,
+		Path:      FormatDerivationPath(path),
+		Accounts: map[string]*tbfunctions.WalletAccount{
+			strconv.FormatUint(uint64(account), 10): {XPub: xpub, Cursor: index + 1},
+		},
+	}
+	err = tbfunctions.SaveWallet(config.WalletPath, wallet)
+	if err != nil {
+		fmt.Println("Error saving wallet:", err)
+		return
+	}
+
+	fmt.Printf("Wallet Address (%s): %s\n", FormatDerivationPath(path), tbfunctions.DisplayAddress(address))
+	fmt.Println("Watch-only wallet saved to:", config.WalletPath)
+	fmt.Println("It can show addresses and balances and build unsigned transactions, but not sign.")
+}
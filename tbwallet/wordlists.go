@@ -0,0 +1,121 @@
+// wordlists.go
+package tbwallet
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/tyler-smith/go-bip39/wordlists"
+	"golang.org/x/text/unicode/norm"
+)
+
+// Languages lists the supported BIP-39 wordlists, in the order used to break
+// ties when a phrase matches more than one list
+var Languages = []string{
+	"english",
+	"spanish",
+	"french",
+	"italian",
+	"japanese",
+	"korean",
+	"chinese-simplified",
+	"chinese-traditional",
+	"czech",
+}
+
+// ErrWordlistNotBundled is returned for a standard BIP-39 language whose list isn't bundled
+var ErrWordlistNotBundled = errors.New("wordlist is not bundled")
+
+// Japanese phrases are written with the ideographic space between words
+const ideographicSpace = "　"
+
+// LoadLanguageWordlist returns the NFKD normalized BIP-39 wordlist of a language
+func LoadLanguageWordlist(language string) ([]string, error) {
+	var wordlist []string
+	switch language {
+	case "english":
+		english, err := LoadWordlist()
+		if err != nil {
+			return nil, err
+		}
+		wordlist = english
+	case "spanish":
+		wordlist = wordlists.Spanish
+	case "french":
+		wordlist = wordlists.French
+	case "italian":
+		wordlist = wordlists.Italian
+	case "japanese":
+		wordlist = wordlists.Japanese
+	case "korean":
+		wordlist = wordlists.Korean
+	case "chinese-simplified":
+		wordlist = wordlists.ChineseSimplified
+	case "chinese-traditional":
+		wordlist = wordlists.ChineseTraditional
+	case "czech":
+		wordlist = wordlists.Czech
+	case "portuguese":
+		// A standard BIP-39 list that go-bip39 doesn't ship, it is reported as
+		// missing until bip-0039/portuguese.txt of bitcoin/bips is vendored
+		return nil, fmt.Errorf("%w: %s phrases can't be created or recovered yet, choose one of: %s",
+			ErrWordlistNotBundled, language, strings.Join(Languages, ", "))
+	default:
+		return nil, fmt.Errorf("unsupported language %q, choose one of: %s", language, strings.Join(Languages, ", "))
+	}
+
+	normalized := make([]string, len(wordlist))
+	for i, word := range wordlist {
+		normalized[i] = norm.NFKD.String(word)
+	}
+	return normalized, nil
+}
+
+// JoinMnemonic joins phrase words with the separator used by the language
+func JoinMnemonic(words []string, language string) string {
+	if language == "japanese" {
+		return strings.Join(words, ideographicSpace)
+	}
+	return strings.Join(words, " ")
+}
+
+// NormalizeMnemonic applies NFKD and splits the phrase on any whitespace,
+// including the ideographic space, returning lower cased words
+func NormalizeMnemonic(mnemonic string) []string {
+	return strings.Fields(strings.ToLower(norm.NFKD.String(mnemonic)))
+}
+
+// DetectLanguage picks the wordlist that contains the most words of the phrase.
+// When several lists contain every word, the one with a valid checksum wins.
+func DetectLanguage(words []string) (string, []string, error) {
+	bestLanguage := ""
+	var bestWordlist []string
+	bestMatches := -1
+	bestValid := false
+	for _, language := range Languages {
+		wordlist, err := LoadLanguageWordlist(language)
+		if err != nil {
+			return "", nil, err
+		}
+		wordIndex := make(map[string]bool, len(wordlist))
+		for _, word := range wordlist {
+			wordIndex[word] = true
+		}
+		matches := 0
+		for _, word := range words {
+			if wordIndex[word] {
+				matches++
+			}
+		}
+		isValid := false
+		if matches == len(words) {
+			_, err := MnemonicToEntropy(strings.Join(words, " "), wordlist)
+			isValid = err == nil
+		}
+		if matches > bestMatches || (matches == bestMatches && isValid && !bestValid) {
+			bestLanguage, bestWordlist, bestMatches, bestValid = language, wordlist, matches, isValid
+		}
+	}
+	return bestLanguage, bestWordlist, nil
+}
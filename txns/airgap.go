@@ -0,0 +1,221 @@
+// airgap.go
+package txns
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+
+	"tbwallet/amounts"
+	"tbwallet/tbfunctions"
+	"tbwallet/tbwallet"
+)
+
+// PrepareTxn checks the txn command line against the wallet and the node and
+// builds the unsigned transaction file. It returns the file and the numbered
+// transaction folder it belongs in.
+func PrepareTxn(fromAccount uint32, fromIndex uint32) (TxnFile, string, bool) {
+	isError, isInputsVerified, dataMap := VerifyTxnInputs(fromAccount, fromIndex)
+	if !isInputsVerified && isError != "" {
+		fmt.Println(isError)
+		return TxnFile{}, "", false
+	} else if !isInputsVerified && isError == "" {
+		fmt.Println("Transaction inputs cannot be verified")
+		return TxnFile{}, "", false
+	}
+	// The fee flags are checked before the node is asked and a nonce is reserved
+	config, err := tbfunctions.LoadConfig()
+	if err != nil {
+		fmt.Println("Error loading config:", err)
+		return TxnFile{}, "", false
+	}
+	feeRate, feeTier, err := TxnFeeRate(config, config.Network)
+	if err != nil {
+		fmt.Println("Error:", err)
+		return TxnFile{}, "", false
+	}
+	maxFee, err := MaxFee()
+	if err != nil {
+		fmt.Println("Error:", err)
+		return TxnFile{}, "", false
+	}
+	// amount converted back to Hanas
+	amount, err := strconv.ParseUint(dataMap["amount_hb"], 10, 64)
+	if err != nil {
+		fmt.Println("Error converting amount_hb to Hanas:", err)
+		return TxnFile{}, "", false
+	}
+	isTxnVerified, returnError, txnMap := VerifyTxn(dataMap["rec_address"], dataMap["tx_data"], amount, fromAccount, fromIndex)
+	if !isTxnVerified && returnError != "" {
+		fmt.Println(returnError)
+		return TxnFile{}, "", false
+	} else if !isTxnVerified && returnError == "" {
+		fmt.Println("Transaction verification failed")
+		return TxnFile{}, "", false
+	}
+
+	tx, err := BuildUnsignedTxn(txnMap["tx_sAddress"], txnMap["tx_amount"], txnMap["tx_nonce"], txnMap["tx_raddress"], txnMap["tx_data"], feeRate)
+	if err != nil {
+		fmt.Println("Error building transaction:", err)
+		nonce, _ := strconv.ParseUint(txnMap["tx_nonce"], 10, 64)
+		ReleaseNonce(txnMap["networkType"], txnMap["tx_sAddress"], nonce, "")
+		return TxnFile{}, "", false
+	}
+	if returnError, isBelow := checkMaxFee(tx, maxFee); !isBelow {
+		fmt.Println(returnError)
+		ReleaseNonce(txnMap["networkType"], tx.Sender, tx.Nonce, "")
+		return TxnFile{}, "", false
+	}
+	// ParseUint saturates, a balance beyond the amount field shows as its maximum
+	balance, _ := strconv.ParseUint(txnMap["balance"], 10, 64)
+	context := TxnContext{
+		Balance:        balance,
+		Account:        fromAccount,
+		Index:          fromIndex,
+		RecipientLabel: dataMap["rec_label"],
+		FeeTier:        feeTier,
+	}
+	return NewTxnFile(tx, txnMap["networkType"], context), txnMap["txnFolder"], true
+}
+
+// BuildTxnFile runs "txn build", it needs the wallet address but not the key
+// so it works on a watch-only wallet
+func BuildTxnFile(fromAccount uint32, fromIndex uint32) bool {
+	txnFile, txnFolder, isBuilt := PrepareTxn(fromAccount, fromIndex)
+	if !isBuilt {
+		return false
+	}
+	filename, isGiven := tbfunctions.FlagValue("--out")
+	if !isGiven {
+		filename = filepath.Join(txnFolder, "unsigned.json")
+	}
+	err := SaveTxnFile(filename, txnFile)
+	if err != nil {
+		fmt.Println("Error creating transaction file:", err)
+		ReleaseNonce(txnFile.Network, txnFile.Transaction.Sender, txnFile.Transaction.Nonce, "")
+		return false
+	}
+	PrintTxnReview(txnFile)
+	fmt.Println(`
+  +-----------------------------------------+
+  |  Unsigned Transaction Built             |
+  +-----------------------------------------+
+
+  File : ` + filename + `
+  Copy it to the offline wallet and run: tbwallet txn sign <file>
+`)
+	return true
+}
+
+// SignTxnFile runs "txn sign" on the offline machine. The transaction is
+// reviewed in full and signed only after confirmation. The signed file is
+// written next to the unsigned one as txn.json unless --out is given.
+func SignTxnFile(filename string) bool {
+	txnFile, err := LoadTxnFile(filename)
+	if err != nil {
+		fmt.Println("Error:", err)
+		return false
+	}
+	if txnFile.Status != TxnStatusUnsigned {
+		fmt.Println("Error: transaction is already signed, run: tbwallet txn broadcast <file>")
+		return false
+	}
+	if tbwallet.IsWatchOnly() {
+		fmt.Println("Error: this is a watch-only wallet, sign on the wallet that holds the seed")
+		return false
+	}
+	err = checkTxnNetwork(txnFile)
+	if err != nil {
+		fmt.Println("Error:", err)
+		return false
+	}
+	tx := txnFile.Transaction
+	if tx.Fee < tx.RequiredFee() {
+		unit := tbfunctions.DisplayUnit()
+		fmt.Printf("Error: fee of %s is below the %s required for %d bytes\n", amounts.FormatUint(tx.Fee, unit), amounts.FormatUint(tx.RequiredFee(), unit), tx.SignedSize())
+		return false
+	}
+	maxFee, err := MaxFee()
+	if err != nil {
+		fmt.Println("Error:", err)
+		return false
+	}
+	if returnError, isBelow := checkMaxFee(tx, maxFee); !isBelow {
+		fmt.Println(returnError)
+		return false
+	}
+
+	PrintTxnReview(txnFile)
+	if confirmTxn("Sign Transaction") != nil {
+		fmt.Println(`
+  +-------------------------+
+  |  Transaction Declined   |
+  +-------------------------+`)
+		return false
+	}
+	isTxSigned, tx := SignTxn(tx, txnFile.Context.Account, txnFile.Context.Index)
+	if !isTxSigned {
+		fmt.Println("Failed to sign the transaction")
+		return false
+	}
+	txnFile.Transaction = tx
+	txnFile.Status = TxnStatusSigned
+	txnFile.Description = "Signed Tulobyte transaction, broadcast it online with: tbwallet txn broadcast <file>"
+
+	signedFilename, isGiven := tbfunctions.FlagValue("--out")
+	if !isGiven {
+		signedFilename = filepath.Join(filepath.Dir(filename), "txn.json")
+	}
+	err = SaveTxnFile(signedFilename, txnFile)
+	if err != nil {
+		fmt.Println("Error creating transaction file:", err)
+		return false
+	}
+	fmt.Println(`
+  +-----------------------------------+
+  |  Transaction Signed Successfully  |
+  +-----------------------------------+
+
+  Hash : ` + tx.Hash + `
+  File : ` + signedFilename + `
+  Copy it to the online machine and run: tbwallet txn broadcast <file>
+`)
+	return true
+}
+
+// BroadcastTxnFile runs "txn broadcast" with a file signed by txn sign. Every
+// txn verify check must pass before the transaction is sent. It returns the
+// exit code of BroadcastTxn, or ExitTxnError when the file can't be sent.
+func BroadcastTxnFile(filename string) int {
+	txnFile, err := LoadTxnFile(filename)
+	if err != nil {
+		fmt.Println("Error:", err)
+		return ExitTxnError
+	}
+	if txnFile.Status != TxnStatusSigned {
+		fmt.Println("Error: transaction isn't signed, run: tbwallet txn sign <file>")
+		return ExitTxnError
+	}
+	err = checkTxnNetwork(txnFile)
+	if err != nil {
+		fmt.Println("Error:", err)
+		return ExitTxnError
+	}
+	for _, check := range checkTxn(txnFile.Transaction) {
+		if !check.passed {
+			fmt.Printf("Error: %s check failed: %s\n", check.name, check.detail)
+			fmt.Println("Run \"tbwallet txn verify " + filename + "\" for the full report")
+			return ExitTxnError
+		}
+	}
+
+	PrintTxnReview(txnFile)
+	if confirmTxn("Broadcast Transaction") != nil {
+		fmt.Println(`
+  +-------------------------+
+  |  Transaction Declined   |
+  +-------------------------+`)
+		return ExitTxnError
+	}
+	return BroadcastTxn(txnFile, filepath.Dir(filename))
+}
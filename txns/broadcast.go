@@ -0,0 +1,173 @@
+// broadcast.go
+package txns
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"tbwallet/rpcclient"
+	"tbwallet/tbfunctions"
+)
+
+// Exit codes of txn broadcast and of txn when the transaction is broadcast
+const (
+	ExitTxnError      = 1 // invalid input or file, nothing was sent
+	ExitNodeFailed    = 2 // node unreachable, timed out or answered garbage
+	ExitTxnRejected   = 3 // node rejected the transaction
+	ExitTxnDuplicate  = 4 // node already has the transaction
+	ExitNonceConflict = 5 // nonce too low or too high
+)
+
+// Broadcast statuses recorded in broadcast.json
+const (
+	BroadcastSent          = "broadcast"
+	BroadcastFailed        = "failed"
+	BroadcastRejected      = "rejected"
+	BroadcastDuplicate     = "duplicate"
+	BroadcastNonceConflict = "nonce-conflict"
+)
+
+// BroadcastRecord is written next to txn.json after every broadcast attempt
+type BroadcastRecord struct {
+	Status   string          `json:"status"`
+	Hash     string          `json:"hash"`
+	Network  string          `json:"network"`
+	Node     string          `json:"node"`
+	Time     int64           `json:"time"`
+	Response json.RawMessage `json:"response,omitempty"`
+	Error    string          `json:"error,omitempty"`
+}
+
+// broadcastStatus sorts a SendRawTransaction error into a status and exit code
+func broadcastStatus(err error) (string, int) {
+	var rpcError *rpcclient.RPCError
+	switch {
+	case err == nil:
+		return BroadcastSent, 0
+	case errors.Is(err, rpcclient.ErrTxnKnown):
+		return BroadcastDuplicate, ExitTxnDuplicate
+	case errors.Is(err, rpcclient.ErrNonceTooLow), errors.Is(err, rpcclient.ErrNonceTooHigh):
+		return BroadcastNonceConflict, ExitNonceConflict
+	case errors.As(err, &rpcError):
+		return BroadcastRejected, ExitTxnRejected
+	default:
+		return BroadcastFailed, ExitNodeFailed
+	}
+}
+
+// BroadcastTxn sends a signed transaction to the node of its network and
+// records the outcome in txnFolder/broadcast.json. It returns 0 when the node
+// accepted the transaction, otherwise one of the Exit codes.
+func BroadcastTxn(txnFile TxnFile, txnFolder string) int {
+	tx := txnFile.Transaction
+	signed, err := tx.EncodeSigned()
+	if err != nil {
+		fmt.Println("Error:", err)
+		return ExitTxnError
+	}
+	config, err := tbfunctions.LoadConfig()
+	if err != nil {
+		fmt.Println("Error loading config:", err)
+		return ExitTxnError
+	}
+	client, err := tbfunctions.NodeClient(txnFile.Network)
+	if err != nil {
+		fmt.Println("Error:", err)
+		return ExitTxnError
+	}
+
+	nodeHash, err := client.SendRawTransaction(context.Background(), signed)
+	status, exitCode := broadcastStatus(err)
+	record := BroadcastRecord{
+		Status:  status,
+		Hash:    tx.Hash,
+		Network: txnFile.Network,
+		Node:    config.RPCURL(txnFile.Network),
+		Time:    time.Now().Unix(),
+	}
+	var rpcError *rpcclient.RPCError
+	if err == nil {
+		record.Response, _ = json.Marshal(nodeHash)
+	} else if errors.As(err, &rpcError) {
+		record.Response, _ = json.Marshal(rpcError)
+		record.Error = err.Error()
+	} else {
+		record.Error = err.Error()
+	}
+	recordErr := SaveBroadcastRecord(txnFolder, record)
+	if recordErr != nil {
+		fmt.Println("Error saving broadcast status:", recordErr)
+	}
+	// The node has the transaction after sent and duplicate, and will never take
+	// it after rejected and nonce-conflict. After failed the node may have it, so
+	// the nonce stays signed for a retry of txn broadcast.
+	var ledgerErr error
+	switch status {
+	case BroadcastSent, BroadcastDuplicate:
+		ledgerErr = UpdateNonce(txnFile.Network, tx.Sender, tx.Nonce, tx.Hash, NonceBroadcast)
+	case BroadcastRejected, BroadcastNonceConflict:
+		ledgerErr = ReleaseNonce(txnFile.Network, tx.Sender, tx.Nonce, tx.Hash)
+	}
+	if ledgerErr != nil {
+		fmt.Println("Error updating nonce ledger:", ledgerErr)
+	}
+
+	switch status {
+	case BroadcastSent:
+		fmt.Println(`
+  +----------------------------+
+  |  Transaction Broadcasted   |
+  +----------------------------+
+
+  Hash : ` + tx.Hash)
+		if !strings.EqualFold(nodeHash, tx.Hash) {
+			fmt.Println("  Warning: the node reported hash " + nodeHash)
+		}
+	case BroadcastDuplicate:
+		fmt.Println(`
++--------------------------------------------------+
+| Error: Node already has this transaction         |
++--------------------------------------------------+
+  Hash   : ` + tx.Hash + `
+  Reason : ` + err.Error())
+	case BroadcastNonceConflict:
+		fmt.Println(`
++--------------------------------------------------+
+| Error: Transaction nonce conflicts with the node |
++--------------------------------------------------+
+  Nonce  : ` + fmt.Sprint(tx.Nonce) + `
+  Reason : ` + err.Error() + `
+  Build the transaction again to use the current nonce.`)
+	case BroadcastRejected:
+		fmt.Println(`
++--------------------------------------------------+
+| Error: Node rejected the transaction             |
++--------------------------------------------------+
+  Reason : ` + err.Error())
+	default:
+		fmt.Println(`
++--------------------------------------------------+
+| Error: Transaction could not be sent             |
++--------------------------------------------------+
+  Node   : ` + record.Node + `
+  Reason : ` + err.Error() + `
+  Nonce ` + fmt.Sprint(tx.Nonce) + ` stays reserved, retry with: tbwallet txn broadcast <file>
+  or free it with: tbwallet nonce reset`)
+	}
+	return exitCode
+}
+
+// SaveBroadcastRecord writes broadcast.json in the transaction folder
+func SaveBroadcastRecord(txnFolder string, record BroadcastRecord) error {
+	data, err := json.MarshalIndent(record, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(txnFolder, "broadcast.json"), data, 0644)
+}
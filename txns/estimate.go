@@ -0,0 +1,219 @@
+// estimate.go
+package txns
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"strings"
+	"time"
+
+	"tbwallet/amounts"
+	"tbwallet/keys"
+	"tbwallet/tbfunctions"
+	"tbwallet/tbwallet"
+)
+
+// placeholderSignature stands in for the signature when estimating, only its
+// length counts
+var placeholderSignature = strings.Repeat("00", SignatureSize)
+
+// TxnEstimate is the size and fees of a transaction before it is signed
+type TxnEstimate struct {
+	Batch      uint8
+	Attachment string
+	Size       int
+	Fees       []uint64
+	Err        error
+}
+
+// Data attachment options of an estimate. txn sends DATA as typed, which is
+// the text option.
+const (
+	AttachmentNone   = "none"
+	AttachmentText   = "text"
+	AttachmentFile   = "file"
+	AttachmentBase64 = "base64"
+)
+
+// feeColumn is a fee rate of the estimate table
+type feeColumn struct {
+	name string
+	rate uint64
+}
+
+// EstimateTxn encodes tx with a placeholder signature and returns the size of
+// the signed encoding and its fee at each of rates. The key isn't used.
+func EstimateTxn(tx Transaction, rates []uint64) (int, []uint64, error) {
+	tx.Signature = placeholderSignature
+	encoded, err := tx.EncodeSigned()
+	if err != nil {
+		return 0, nil, err
+	}
+	fees := make([]uint64, len(rates))
+	for i, rate := range rates {
+		fees[i] = tx.FeeAt(rate)
+	}
+	return len(encoded), fees, nil
+}
+
+// estimateAttachments returns the data of every attachment option of dataInput,
+// in table order: no data, the text as typed and, when it names a file, the
+// file bytes and the file as base64. Files are read up to one byte past the
+// data limit, a larger file is reported as too large either way.
+func estimateAttachments(dataInput string) (map[string]string, []string) {
+	attachments := map[string]string{AttachmentNone: "", AttachmentText: dataInput}
+	names := []string{AttachmentNone, AttachmentText}
+	info, err := os.Stat(dataInput)
+	if err != nil || !info.Mode().IsRegular() {
+		return attachments, names
+	}
+	file, err := os.Open(dataInput)
+	if err != nil {
+		return attachments, names
+	}
+	defer file.Close()
+	contents, err := io.ReadAll(io.LimitReader(file, MaxTxnDataSize+1))
+	if err != nil {
+		return attachments, names
+	}
+	attachments[AttachmentFile] = string(contents)
+	attachments[AttachmentBase64] = base64.StdEncoding.EncodeToString(contents)
+	return attachments, append(names, AttachmentFile, AttachmentBase64)
+}
+
+// EstimateTxnFees runs "tbwallet txn estimate", it prints the size and fee of
+// the transaction for each batch type, data attachment option and fee tier.
+func EstimateTxnFees(fromAccount uint32, fromIndex uint32) bool {
+	args := tbfunctions.PositionalArgs("--account", "--from-index", "--network", "--fee-tier", "--fee-rate", "--max-fee", "--unit")
+	if len(args) != 6 {
+		tbfunctions.PrintTxnHelp()
+		return false
+	}
+	config, err := tbfunctions.LoadConfig()
+	if err != nil {
+		fmt.Println("Error loading config:", err)
+		return false
+	}
+	network, isGiven := tbfunctions.FlagValue("--network")
+	if !isGiven {
+		network = config.Network
+	} else if _, ok := tbfunctions.NetworkHRPs[network]; !ok {
+		fmt.Println("Error: unknown network, use mainnet or testnet:", network)
+		return false
+	}
+	feeRate, feeTier, err := TxnFeeRate(config, network)
+	if err != nil {
+		fmt.Println("Error:", err)
+		return false
+	}
+	maxFee, err := MaxFee()
+	if err != nil {
+		fmt.Println("Error:", err)
+		return false
+	}
+	feeRates := config.NetworkFeeRates(network)
+	var columns []feeColumn
+	for _, tier := range tbfunctions.FeeTiers {
+		rate, _ := feeRates.Rate(tier)
+		columns = append(columns, feeColumn{tier, rate})
+	}
+	if feeTier == "" {
+		columns = append(columns, feeColumn{fmt.Sprintf("%d/byte", feeRate), feeRate})
+	}
+	rates := make([]uint64, len(columns))
+	for i, column := range columns {
+		rates[i] = column.rate
+	}
+
+	receiver, err := resolveRecipient(args[3], network)
+	if err != nil {
+		fmt.Println("Error:", err)
+		return false
+	}
+	amount, err := parseTxnAmount(args[4])
+	if err != nil {
+		fmt.Println("Error:", err)
+		return false
+	}
+	// The sender only fills its fixed width, the zero address does without a wallet
+	sender := "0x" + strings.Repeat("00", keys.AddressSize)
+	if address, _, err := tbwallet.WalletAddress(fromAccount, fromIndex); err == nil {
+		sender = strings.ToLower(address)
+	}
+
+	attachments, names := estimateAttachments(args[5])
+	var estimates []TxnEstimate
+	for _, batch := range []uint8{BatchHunter, BatchNormal} {
+		for _, name := range names {
+			tx := Transaction{
+				Sender:    sender,
+				Receiver:  receiver,
+				Timestamp: time.Now().Unix(),
+				Amount:    amount,
+				Batch:     batch,
+				Data:      attachments[name],
+			}
+			estimate := TxnEstimate{Batch: batch, Attachment: name}
+			estimate.Size, estimate.Fees, estimate.Err = EstimateTxn(tx, rates)
+			estimates = append(estimates, estimate)
+		}
+	}
+
+	fmt.Println("Recipient : " + tbfunctions.DisplayAddress(receiver))
+	fmt.Println("Network   : " + network)
+	unit := tbfunctions.DisplayUnit()
+	fmt.Printf("Amount    : %s\n\n", amounts.FormatUint(amount, unit))
+	// The --fee-rate column comes last
+	chosen := feeTier
+	if chosen == "" {
+		chosen = columns[len(columns)-1].name
+	}
+	fmt.Printf("  %-8s %-8s %10s", "BATCH", "DATA", "SIZE")
+	for _, column := range columns {
+		name := strings.ToUpper(column.name)
+		if column.name == chosen {
+			name += "*"
+		}
+		fmt.Printf(" %12s", name)
+	}
+	fmt.Println()
+	for _, estimate := range estimates {
+		batch := "Normal"
+		if estimate.Batch == BatchHunter {
+			batch = "Hunter"
+		}
+		if estimate.Batch == ConfigBatch(config) {
+			batch += "*"
+		}
+		attachment := estimate.Attachment
+		if attachment == AttachmentText {
+			attachment += "*"
+		}
+		if estimate.Err != nil {
+			fmt.Printf("  %-8s %-8s %s\n", batch, attachment, estimate.Err)
+			continue
+		}
+		fmt.Printf("  %-8s %-8s %10d", batch, attachment, estimate.Size)
+		for _, fee := range estimate.Fees {
+			value := amounts.FormatValue(new(big.Int).SetUint64(fee), unit)
+			if maxFee != 0 && fee > maxFee {
+				value += "!"
+			}
+			fmt.Printf(" %12s", value)
+		}
+		fmt.Println()
+	}
+	fmt.Printf("\nSize in bytes of the signed encoding, fees in %s at slow %d, normal %d and fast %d Hanas per byte.\n",
+		unit, feeRates.Slow, feeRates.Normal, feeRates.Fast)
+	fmt.Println("* is the batch, data and fee a txn would use, txn sends DATA as typed. The signature is a placeholder, no key was used.")
+	if len(names) == 2 {
+		fmt.Println("Give the path of a file as DATA to also estimate it attached as bytes and as base64.")
+	}
+	if maxFee != 0 {
+		fmt.Printf("! is above --max-fee of %s, txn would stop before signing.\n", amounts.FormatUint(maxFee, unit))
+	}
+	return true
+}
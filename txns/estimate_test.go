@@ -0,0 +1,62 @@
+// estimate_test.go
+package txns
+
+import (
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestEstimateTxn(t *testing.T) {
+	tx := Transaction{
+		Sender:    "0x9d8a62f656a8d1615c1294fd71e9cfb3e4855a4f",
+		Receiver:  "0x6fac4d18c912343bf86fa7049364dd4e424ab9c0",
+		Timestamp: 1700000000,
+		Amount:    100,
+		Batch:     BatchNormal,
+		Data:      "hi",
+	}
+	// The first vector of docs/transaction-encoding.md is 149 bytes signed
+	size, fees, err := EstimateTxn(tx, []uint64{10, 15})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if size != 149 || len(fees) != 2 || fees[0] != 1490 || fees[1] != 2235 {
+		t.Errorf("EstimateTxn = %d, %v, want 149, [1490 2235]", size, fees)
+	}
+
+	tx.Data = string(make([]byte, MaxTxnDataSize+1))
+	if _, _, err := EstimateTxn(tx, []uint64{10}); err == nil {
+		t.Error("EstimateTxn accepted data above the limit")
+	}
+}
+
+func TestEstimateAttachments(t *testing.T) {
+	attachments, names := estimateAttachments("hello")
+	if len(names) != 2 || names[0] != AttachmentNone || names[1] != AttachmentText {
+		t.Fatalf("names = %v, want [none text]", names)
+	}
+	if attachments[AttachmentNone] != "" || attachments[AttachmentText] != "hello" {
+		t.Errorf("attachments = %q", attachments)
+	}
+
+	filename := filepath.Join(t.TempDir(), "data.bin")
+	contents := []byte{0x00, 0x01, 0xfe, 0xff, 'x'}
+	if err := os.WriteFile(filename, contents, 0600); err != nil {
+		t.Fatal(err)
+	}
+	attachments, names = estimateAttachments(filename)
+	if len(names) != 4 || names[2] != AttachmentFile || names[3] != AttachmentBase64 {
+		t.Fatalf("names = %v, want [none text file base64]", names)
+	}
+	if attachments[AttachmentText] != filename || attachments[AttachmentFile] != string(contents) ||
+		attachments[AttachmentBase64] != base64.StdEncoding.EncodeToString(contents) {
+		t.Errorf("attachments = %q", attachments)
+	}
+
+	// A directory isn't a file attachment
+	if _, names := estimateAttachments(t.TempDir()); len(names) != 2 {
+		t.Errorf("directory names = %v, want [none text]", names)
+	}
+}
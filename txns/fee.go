@@ -0,0 +1,93 @@
+// fee.go
+package txns
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"tbwallet/amounts"
+	"tbwallet/tbfunctions"
+)
+
+// maxFeeRate keeps the fee of the largest transaction within the fee field
+const maxFeeRate = math.MaxUint64 / uint64(txnHeaderSize+MaxTxnDataSize+SignatureSize)
+
+// TxnFeeRate returns the fee rate of a txn in Hanas per byte and the tier it
+// comes from. --fee-rate wins over --fee-tier, which wins over the configured
+// tier. The tier is empty for --fee-rate.
+func TxnFeeRate(config tbfunctions.Config, network string) (uint64, string, error) {
+	rateInput, isRateGiven := tbfunctions.FlagValue("--fee-rate")
+	tier, isTierGiven := tbfunctions.FlagValue("--fee-tier")
+	if isRateGiven && isTierGiven {
+		return 0, "", errors.New("give --fee-rate or --fee-tier, not both")
+	}
+	if isRateGiven {
+		rate, err := strconv.ParseUint(rateInput, 10, 64)
+		if err != nil {
+			return 0, "", fmt.Errorf("invalid --fee-rate %q, give Hanas per byte", rateInput)
+		}
+		return rate, "", checkFeeRate(rate)
+	}
+	if !isTierGiven {
+		tier = config.DefaultFeeTier()
+	}
+	tier = strings.ToLower(tier)
+	rate, ok := config.NetworkFeeRates(network).Rate(tier)
+	if !ok {
+		return 0, "", fmt.Errorf("invalid fee tier %q, use slow, normal or fast", tier)
+	}
+	err := checkFeeRate(rate)
+	if err != nil {
+		return 0, "", fmt.Errorf("%s tier of %s: %w", tier, network, err)
+	}
+	return rate, tier, nil
+}
+
+// checkFeeRate rejects rates the node doesn't accept or the fee field can't hold
+func checkFeeRate(rate uint64) error {
+	if rate < MinFeePerByte {
+		return fmt.Errorf("fee rate of %d Hanas per byte is below the minimum of %d", rate, MinFeePerByte)
+	}
+	if rate > maxFeeRate {
+		return fmt.Errorf("fee rate of %d Hanas per byte is too high", rate)
+	}
+	return nil
+}
+
+// MaxFee returns the --max-fee ceiling in Hanas, 0 when it isn't given
+func MaxFee() (uint64, error) {
+	input, isGiven := tbfunctions.FlagValue("--max-fee")
+	if !isGiven {
+		return 0, nil
+	}
+	hanas, err := amounts.Parse(input, tbfunctions.InputUnit())
+	if err != nil {
+		return 0, fmt.Errorf("--max-fee: %w", err)
+	}
+	if hanas.Sign() == 0 {
+		return 0, errors.New("--max-fee must be above 0")
+	}
+	if !hanas.IsUint64() {
+		// Above any fee the fee field can hold
+		return math.MaxUint64, nil
+	}
+	return hanas.Uint64(), nil
+}
+
+// checkMaxFee reports the --max-fee error when the fee of tx exceeds maxFee
+func checkMaxFee(tx Transaction, maxFee uint64) (string, bool) {
+	if maxFee == 0 || tx.Fee <= maxFee {
+		return "", true
+	}
+	returnError := `
++------------------------------------------------+
+| Error:  Fee is above --max-fee                 |
+| Reason: The transaction was not signed         |
++------------------------------------------------+
+  Fee     : ` + amounts.FormatUint(tx.Fee, tbfunctions.DisplayUnit()) + ` for ` + strconv.Itoa(tx.SignedSize()) + ` bytes
+  Max fee : ` + amounts.FormatUint(maxFee, tbfunctions.DisplayUnit())
+	return returnError, false
+}
@@ -0,0 +1,378 @@
+// history.go
+package txns
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"tbwallet/amounts"
+	"tbwallet/tbfunctions"
+)
+
+// Default number of transactions per history page
+const historyPageSize = 20
+
+// HistoryEntry is a transaction folder read back from the txns store
+type HistoryEntry struct {
+	Folder      string
+	File        string
+	Network     string
+	Status      string
+	Transaction Transaction
+	Context     TxnContext
+	Broadcast   *BroadcastRecord
+	// NonceUnknown is set for older txn.json files signed without a nonce
+	NonceUnknown bool
+}
+
+// legacyUnknownNonce is the nonce older txn.json files hold when the node gave none
+const legacyUnknownNonce = "-1"
+
+// historyFilter holds the history command filters, zero values match everything
+type historyFilter struct {
+	to        string
+	since     int64
+	minAmount uint64
+	status    string
+}
+
+func (filter historyFilter) matches(entry HistoryEntry) bool {
+	tx := entry.Transaction
+	return (filter.to == "" || strings.EqualFold(tx.Receiver, filter.to)) &&
+		tx.Timestamp >= filter.since &&
+		tx.Amount >= filter.minAmount &&
+		(filter.status == "" || entry.Status == filter.status)
+}
+
+// decodeLegacyTxn reads an older txn.json, the bare transaction. Its nonce is
+// "-1" when the node gave none, it is then read as 0 and reported as unknown.
+func decodeLegacyTxn(data []byte) (Transaction, bool, error) {
+	var tx Transaction
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return tx, false, err
+	}
+	nonceUnknown := string(fields["n"]) == `"`+legacyUnknownNonce+`"`
+	if nonceUnknown {
+		fields["n"] = json.RawMessage(`"0"`)
+		data, _ = json.Marshal(fields)
+	}
+	err := json.Unmarshal(data, &tx)
+	return tx, nonceUnknown, err
+}
+
+// readTxnFolder reads the transaction of a folder. A signed txn.json wins over
+// unsigned.json, and broadcast.json sets the status when the transaction was sent.
+// It returns false for a folder without a transaction file, and the error of a
+// file it can't read when no other file could be read.
+func readTxnFolder(folder string, network string) (HistoryEntry, bool, error) {
+	entry := HistoryEntry{Folder: folder, Network: network}
+	var readErr error
+	for _, name := range []string{"txn.json", "unsigned.json"} {
+		filename := filepath.Join(folder, name)
+		data, err := os.ReadFile(filename)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			readErr = err
+			continue
+		}
+		// Files of txn build and txn sign wrap the transaction, older txn.json files are the bare transaction
+		var txnFile TxnFile
+		if err := json.Unmarshal(data, &txnFile); err != nil {
+			readErr = fmt.Errorf("%s: %w", filename, err)
+			continue
+		}
+		if txnFile.Format == "" {
+			entry.Transaction, entry.NonceUnknown, err = decodeLegacyTxn(data)
+			if err != nil {
+				readErr = fmt.Errorf("%s: %w", filename, err)
+				continue
+			}
+			entry.Status = TxnStatusSigned
+		} else {
+			entry.Transaction = txnFile.Transaction
+			entry.Status = txnFile.Status
+			entry.Context = txnFile.Context
+		}
+		entry.File = filename
+		break
+	}
+	if entry.File == "" {
+		return entry, false, readErr
+	}
+
+	data, err := os.ReadFile(filepath.Join(folder, "broadcast.json"))
+	if err == nil {
+		var record BroadcastRecord
+		if json.Unmarshal(data, &record) == nil {
+			entry.Broadcast = &record
+			entry.Status = record.Status
+		}
+	}
+	return entry, true, nil
+}
+
+// LoadHistory reads every transaction folder of network, newest first. The
+// files it couldn't read are returned with the history, they don't stop it.
+func LoadHistory(network string) ([]HistoryEntry, []error, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		log.Fatalf("Unable to get the user's home directory: %v", err)
+	}
+	txnDir := filepath.Join(homeDir, "tbwallet", network, "txns")
+	folders, err := os.ReadDir(txnDir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var history []HistoryEntry
+	var skipped []error
+	for _, folder := range folders {
+		if !folder.IsDir() {
+			continue
+		}
+		entry, ok, err := readTxnFolder(filepath.Join(txnDir, folder.Name()), network)
+		if ok {
+			history = append(history, entry)
+		} else if err != nil {
+			skipped = append(skipped, err)
+		}
+	}
+	sort.SliceStable(history, func(i, j int) bool {
+		return history[i].Transaction.Timestamp > history[j].Transaction.Timestamp
+	})
+	return history, skipped, nil
+}
+
+// parseSince reads a date, an RFC 3339 time or an age such as 36h or 7d
+func parseSince(value string) (int64, error) {
+	if days, isDays := strings.CutSuffix(value, "d"); isDays {
+		if count, err := strconv.Atoi(days); err == nil && count >= 0 {
+			return time.Now().AddDate(0, 0, -count).Unix(), nil
+		}
+	}
+	if age, err := time.ParseDuration(value); err == nil {
+		return time.Now().Add(-age).Unix(), nil
+	}
+	if date, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+		return date.Unix(), nil
+	}
+	if moment, err := time.Parse(time.RFC3339, value); err == nil {
+		return moment.Unix(), nil
+	}
+	return 0, fmt.Errorf("invalid --since %q, use YYYY-MM-DD, an RFC 3339 time or an age like 36h or 7d", value)
+}
+
+// historyStatuses are the values of --status
+var historyStatuses = []string{TxnStatusUnsigned, TxnStatusSigned, BroadcastSent, BroadcastFailed, BroadcastRejected, BroadcastDuplicate, BroadcastNonceConflict}
+
+// parseHistoryFilter reads the history command filter flags
+func parseHistoryFilter(network string) (historyFilter, error) {
+	var filter historyFilter
+	var err error
+	if value, isGiven := tbfunctions.FlagValue("--to"); isGiven {
+		filter.to, err = resolveRecipient(value, network)
+		if err != nil {
+			return filter, fmt.Errorf("--to: %w", err)
+		}
+	}
+	if value, isGiven := tbfunctions.FlagValue("--since"); isGiven {
+		filter.since, err = parseSince(value)
+		if err != nil {
+			return filter, err
+		}
+	}
+	if value, isGiven := tbfunctions.FlagValue("--min-amount"); isGiven {
+		minAmount, err := amounts.Parse(value, tbfunctions.InputUnit())
+		if err != nil {
+			return filter, fmt.Errorf("--min-amount: %w", err)
+		}
+		filter.minAmount, err = amounts.Uint64(minAmount)
+		if err != nil {
+			return filter, fmt.Errorf("--min-amount: %w", err)
+		}
+	}
+	if value, isGiven := tbfunctions.FlagValue("--status"); isGiven {
+		for _, status := range historyStatuses {
+			if value == status {
+				filter.status = value
+			}
+		}
+		if filter.status == "" {
+			return filter, fmt.Errorf("invalid --status %q, use one of %s", value, strings.Join(historyStatuses, ", "))
+		}
+	}
+	return filter, nil
+}
+
+// shortHash shortens a hash for the history table
+func shortHash(hash string) string {
+	if len(hash) <= 14 {
+		return hash
+	}
+	return hash[:10] + ".." + hash[len(hash)-4:]
+}
+
+// ShowHistory runs "tbwallet history"
+func ShowHistory() {
+	if tbfunctions.HasFlag("-h", "--help") {
+		tbfunctions.PrintHistoryHelp()
+		return
+	}
+	config, err := tbfunctions.LoadConfig()
+	if err != nil {
+		fmt.Println("Error loading config:", err)
+		return
+	}
+	network, isGiven := tbfunctions.FlagValue("--network")
+	if !isGiven {
+		network = config.Network
+	} else if _, ok := tbfunctions.NetworkHRPs[network]; !ok {
+		fmt.Println("Error: unknown network, use mainnet or testnet:", network)
+		return
+	}
+	history, skipped, err := LoadHistory(network)
+	if err != nil {
+		fmt.Println("Error reading transactions:", err)
+		return
+	}
+	for _, err := range skipped {
+		fmt.Println("Skipped", err)
+	}
+
+	if hash, isGiven := tbfunctions.FlagValue("--show"); isGiven {
+		showHistoryEntry(history, hash)
+		return
+	}
+
+	filter, err := parseHistoryFilter(network)
+	if err != nil {
+		fmt.Println("Error:", err)
+		return
+	}
+	pageSize, page := historyPageSize, 1
+	if value, isGiven := tbfunctions.FlagValue("--limit"); isGiven {
+		pageSize, err = strconv.Atoi(value)
+		if err != nil || pageSize < 1 {
+			fmt.Println("Error: invalid --limit:", value)
+			return
+		}
+	}
+	if value, isGiven := tbfunctions.FlagValue("--page"); isGiven {
+		page, err = strconv.Atoi(value)
+		if err != nil || page < 1 {
+			fmt.Println("Error: invalid --page:", value)
+			return
+		}
+	}
+
+	var matched []HistoryEntry
+	for _, entry := range history {
+		if filter.matches(entry) {
+			matched = append(matched, entry)
+		}
+	}
+	if len(history) == 0 {
+		fmt.Println("No transactions on " + network)
+		return
+	} else if len(matched) == 0 {
+		fmt.Println("No transactions on " + network + " match the filters")
+		return
+	}
+	pages := (len(matched) + pageSize - 1) / pageSize
+	if page > pages {
+		fmt.Printf("Error: page %d of %d\n", page, pages)
+		return
+	}
+	start := (page - 1) * pageSize
+	end := min(start+pageSize, len(matched))
+
+	unit := tbfunctions.DisplayUnit()
+	fmt.Printf("%-16s %-5s %-20s %14s %10s %-6s %-16s %s\n", "HASH", "NONCE", "RECIPIENT", "AMOUNT", "FEE", "BATCH", "TIME", "STATUS")
+	for _, entry := range matched[start:end] {
+		tx := entry.Transaction
+		recipient := tbfunctions.DisplayAddress(tx.Receiver)
+		if len(recipient) > 20 {
+			recipient = recipient[:8] + ".." + recipient[len(recipient)-6:]
+		} else if recipient == "" {
+			recipient = "-"
+		}
+		if entry.Context.RecipientLabel != "" {
+			recipient = "@" + entry.Context.RecipientLabel
+		}
+		batch := "Normal"
+		if tx.Batch == BatchHunter {
+			batch = "Hunter"
+		}
+		hash := tx.Hash
+		if hash == "" {
+			hash = "-"
+		}
+		amount := amounts.FormatValue(new(big.Int).SetUint64(tx.Amount), unit)
+		fee := amounts.FormatValue(new(big.Int).SetUint64(tx.Fee), unit)
+		nonce := strconv.FormatUint(tx.Nonce, 10)
+		if entry.NonceUnknown {
+			nonce = "-"
+		}
+		fmt.Printf("%-16s %-5s %-20s %14s %10s %-6s %-16s %s\n", shortHash(hash), nonce, recipient, amount, fee, batch,
+			time.Unix(tx.Timestamp, 0).Format("2006-01-02 15:04"), entry.Status)
+	}
+	fmt.Printf("\nPage %d of %d, %d transactions. Amounts and fees in %s.\n", page, pages, len(matched), unit)
+	if page < pages {
+		fmt.Printf("Next page: tbwallet history --page %d\n", page+1)
+	}
+}
+
+// showHistoryEntry prints the detail view of the transaction whose hash starts with hash
+func showHistoryEntry(history []HistoryEntry, hash string) {
+	var found []HistoryEntry
+	for _, entry := range history {
+		if entry.Transaction.Hash != "" && strings.HasPrefix(strings.ToLower(entry.Transaction.Hash), strings.ToLower(hash)) {
+			found = append(found, entry)
+		}
+	}
+	if len(found) == 0 {
+		fmt.Println("Error: no signed transaction with hash", hash)
+		return
+	}
+	if len(found) > 1 {
+		fmt.Printf("Error: %d transactions start with %s, give more of the hash\n", len(found), hash)
+		return
+	}
+
+	entry := found[0]
+	PrintTxnReview(TxnFile{Network: entry.Network, Transaction: entry.Transaction, Context: entry.Context})
+	fmt.Println("  Hash      : " + entry.Transaction.Hash)
+	fmt.Println("  Signature : " + entry.Transaction.Signature)
+	fmt.Println("  Status    : " + entry.Status)
+	if entry.NonceUnknown {
+		fmt.Println("  Note      : the file has nonce " + legacyUnknownNonce + ", the node gave none when it was signed")
+	}
+	fmt.Println("  File      : " + entry.File)
+	if record := entry.Broadcast; record != nil {
+		fmt.Println("  Node      : " + record.Node)
+		fmt.Println("  Sent at   : " + time.Unix(record.Time, 0).Format("2006-01-02 15:04:05"))
+		var response bytes.Buffer
+		if json.Compact(&response, record.Response) == nil {
+			fmt.Println("  Response  : " + response.String())
+		}
+		if record.Error != "" {
+			fmt.Println("  Error     : " + record.Error)
+		}
+	}
+}
@@ -0,0 +1,26 @@
+// history_test.go
+package txns
+
+import "testing"
+
+func TestDecodeLegacyTxn(t *testing.T) {
+	tests := []struct {
+		data         string
+		nonce        uint64
+		nonceUnknown bool
+	}{
+		{`{"n":"-1","s":"0x9d8a62f656a8d1615c1294fd71e9cfb3e4855a4f","a":"100"}`, 0, true},
+		{`{"n":"7","s":"0x9d8a62f656a8d1615c1294fd71e9cfb3e4855a4f","a":"100"}`, 7, false},
+	}
+	for _, test := range tests {
+		tx, nonceUnknown, err := decodeLegacyTxn([]byte(test.data))
+		if err != nil || tx.Nonce != test.nonce || nonceUnknown != test.nonceUnknown || tx.Amount != 100 {
+			t.Errorf("decodeLegacyTxn(%s) = %+v, %v, %v, want nonce %d, %v", test.data, tx, nonceUnknown, err, test.nonce, test.nonceUnknown)
+		}
+	}
+	for _, data := range []string{`{"n":"-2"}`, `{"n":-1}`, `[]`} {
+		if _, _, err := decodeLegacyTxn([]byte(data)); err == nil {
+			t.Errorf("decodeLegacyTxn(%s) succeeded", data)
+		}
+	}
+}
@@ -0,0 +1,296 @@
+// nonce.go
+package txns
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"tbwallet/rpcclient"
+	"tbwallet/tbfunctions"
+)
+
+// How long to wait for another tbwallet to release the nonce ledger
+const nonceLockTimeout = 10 * time.Second
+
+// Status of a nonce ledger entry
+const (
+	NonceReserved  = "reserved"  // handed out, the transaction isn't signed yet
+	NonceSigned    = "signed"    // signed but not broadcast
+	NonceBroadcast = "broadcast" // sent to the node, waiting for confirmation
+)
+
+// NonceEntry is a nonce in use by a transaction the node hasn't confirmed
+type NonceEntry struct {
+	Nonce  uint64 `json:"nonce"`
+	Status string `json:"status"`
+	Hash   string `json:"hash,omitempty"`
+	Time   int64  `json:"time"`
+}
+
+// nonceLedger holds the unconfirmed nonces of every address of a network
+type nonceLedger map[string][]NonceEntry
+
+// NonceState is the ledger of an address merged with the node's confirmed nonce
+type NonceState struct {
+	Confirmed  uint64
+	Pending    []NonceEntry
+	Next       uint64
+	Gaps       []uint64
+	Duplicates []uint64
+}
+
+// nonceLedgerFile returns ~/tbwallet/<network>/nonces.json
+func nonceLedgerFile(network string) string {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		log.Fatalf("Unable to get the user's home directory: %v", err)
+	}
+	return filepath.Join(homeDir, "tbwallet", network, "nonces.json")
+}
+
+// withNonceLedger runs update on the ledger of network while holding its lock
+// file, and saves the ledger when update succeeds. The lock makes handing out
+// a nonce atomic between tbwallet processes.
+func withNonceLedger(network string, update func(ledger nonceLedger) error) error {
+	filename := nonceLedgerFile(network)
+	err := os.MkdirAll(filepath.Dir(filename), 0755)
+	if err != nil {
+		return err
+	}
+	lockFilename := filename + ".lock"
+	deadline := time.Now().Add(nonceLockTimeout)
+	for {
+		lockFile, err := os.OpenFile(lockFilename, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+		if err == nil {
+			lockFile.Close()
+			break
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return err
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("nonce ledger is locked by another tbwallet, remove %s if none is running", lockFilename)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	defer os.Remove(lockFilename)
+
+	ledger := nonceLedger{}
+	data, err := os.ReadFile(filename)
+	if err == nil {
+		err = json.Unmarshal(data, &ledger)
+		if err != nil {
+			return fmt.Errorf("invalid nonce ledger %s: %w", filename, err)
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	err = update(ledger)
+	if err != nil {
+		return err
+	}
+	data, err = json.MarshalIndent(ledger, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filename, data, 0644)
+}
+
+// mergeNonces drops the entries the node has confirmed and works out the next
+// free nonce, the gaps and the nonces used by more than one transaction
+func mergeNonces(entries []NonceEntry, confirmed uint64) NonceState {
+	state := NonceState{Confirmed: confirmed}
+	used := map[uint64]int{}
+	for _, entry := range entries {
+		if entry.Nonce >= confirmed {
+			state.Pending = append(state.Pending, entry)
+			used[entry.Nonce]++
+		}
+	}
+	sort.Slice(state.Pending, func(i, j int) bool { return state.Pending[i].Nonce < state.Pending[j].Nonce })
+
+	state.Next = confirmed
+	for used[state.Next] > 0 {
+		state.Next++
+	}
+	if len(state.Pending) > 0 {
+		highest := state.Pending[len(state.Pending)-1].Nonce
+		for nonce := confirmed; nonce < highest; nonce++ {
+			if used[nonce] == 0 {
+				state.Gaps = append(state.Gaps, nonce)
+			}
+		}
+	}
+	for nonce, count := range used {
+		if count > 1 {
+			state.Duplicates = append(state.Duplicates, nonce)
+		}
+	}
+	sort.Slice(state.Duplicates, func(i, j int) bool { return state.Duplicates[i] < state.Duplicates[j] })
+	return state
+}
+
+// ReserveNonce hands out the lowest nonce of address that is neither confirmed
+// by the node nor used by a pending transaction, gaps are filled first
+func ReserveNonce(network string, address string, confirmed uint64) (uint64, error) {
+	address = strings.ToLower(address)
+	var nonce uint64
+	err := withNonceLedger(network, func(ledger nonceLedger) error {
+		state := mergeNonces(ledger[address], confirmed)
+		nonce = state.Next
+		ledger[address] = append(state.Pending, NonceEntry{Nonce: nonce, Status: NonceReserved, Time: time.Now().Unix()})
+		return nil
+	})
+	return nonce, err
+}
+
+// UpdateNonce records the hash and status of the transaction using nonce.
+// A reserved entry is taken over, otherwise the entry is added.
+func UpdateNonce(network string, address string, nonce uint64, hash string, status string) error {
+	address = strings.ToLower(address)
+	return withNonceLedger(network, func(ledger nonceLedger) error {
+		entries := ledger[address]
+		for i, entry := range entries {
+			if entry.Nonce == nonce && (entry.Hash == "" || strings.EqualFold(entry.Hash, hash)) {
+				entries[i].Hash = hash
+				entries[i].Status = status
+				entries[i].Time = time.Now().Unix()
+				return nil
+			}
+		}
+		ledger[address] = append(entries, NonceEntry{Nonce: nonce, Status: status, Hash: hash, Time: time.Now().Unix()})
+		return nil
+	})
+}
+
+// ReleaseNonce frees the nonce of a transaction abandoned before it was
+// broadcast. Only the entry of that transaction goes: hash is empty for a
+// reservation that was never signed, other transactions with the same nonce
+// keep theirs.
+func ReleaseNonce(network string, address string, nonce uint64, hash string) error {
+	address = strings.ToLower(address)
+	return withNonceLedger(network, func(ledger nonceLedger) error {
+		entries := ledger[address]
+		for i, entry := range entries {
+			if entry.Nonce == nonce && entry.Status != NonceBroadcast && strings.EqualFold(entry.Hash, hash) {
+				ledger[address] = append(entries[:i:i], entries[i+1:]...)
+				return nil
+			}
+		}
+		return nil
+	})
+}
+
+// DiscardSignedTxn deletes a signed transaction file that won't be broadcast,
+// and its folder when nothing else is in it, then frees its nonce. The nonce
+// stays reserved when the file can't be deleted, it could still be broadcast.
+func DiscardSignedTxn(txnFile TxnFile, filename string) error {
+	err := os.Remove(filename)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("nonce %d stays reserved: %w", txnFile.Transaction.Nonce, err)
+	}
+	// Fails harmlessly when the folder holds other files
+	os.Remove(filepath.Dir(filename))
+	tx := txnFile.Transaction
+	return ReleaseNonce(txnFile.Network, tx.Sender, tx.Nonce, tx.Hash)
+}
+
+// ConfirmedNonce returns the node's transaction count of address, the nonce of
+// the next transaction if nothing is pending
+func ConfirmedNonce(network string, address string) (uint64, error) {
+	client, err := tbfunctions.NodeClient(network)
+	if err != nil {
+		return 0, err
+	}
+	return client.GetTransactionCount(context.Background(), address, rpcclient.TagLatest)
+}
+
+// LoadNonceState merges the ledger of address with the node's confirmed nonce
+func LoadNonceState(network string, address string) (NonceState, error) {
+	confirmed, err := ConfirmedNonce(network, address)
+	if err != nil {
+		return NonceState{}, err
+	}
+	address = strings.ToLower(address)
+	var state NonceState
+	err = withNonceLedger(network, func(ledger nonceLedger) error {
+		state = mergeNonces(ledger[address], confirmed)
+		ledger[address] = state.Pending
+		return nil
+	})
+	return state, err
+}
+
+// ShowNonces runs "tbwallet nonce show"
+func ShowNonces(network string, address string) {
+	state, err := LoadNonceState(network, address)
+	if err != nil {
+		fmt.Println("Error:", err)
+		return
+	}
+	fmt.Println("Address   : " + tbfunctions.DisplayAddress(address))
+	fmt.Println("Network   : " + network)
+	fmt.Printf("Confirmed : %d transactions\n", state.Confirmed)
+	fmt.Printf("Next      : %d\n", state.Next)
+	if len(state.Pending) == 0 {
+		fmt.Println("Pending   : none")
+	} else {
+		fmt.Println("Pending   :")
+		for _, entry := range state.Pending {
+			fmt.Printf("  %-6d %-10s %s  %s\n", entry.Nonce, entry.Status, time.Unix(entry.Time, 0).Format("2006-01-02 15:04:05"), entry.Hash)
+		}
+	}
+	if len(state.Gaps) > 0 {
+		fmt.Println(`
++------------------------------------------------------------+
+| Warning: Nonce gap, later transactions can't be confirmed  |
+|          until the missing nonces are used                 |
++------------------------------------------------------------+`)
+		fmt.Println("  Missing :", formatNonces(state.Gaps))
+	}
+	if len(state.Duplicates) > 0 {
+		fmt.Println(`
++------------------------------------------------------------+
+| Warning: Several transactions use the same nonce, only     |
+|          one of them can be confirmed                      |
++------------------------------------------------------------+`)
+		fmt.Println("  Nonces  :", formatNonces(state.Duplicates))
+	}
+}
+
+// ResetNonces runs "tbwallet nonce reset", it forgets the pending transactions of address
+func ResetNonces(network string, address string) {
+	fmt.Println("This forgets the pending transactions of " + tbfunctions.DisplayAddress(address) + " on " + network + ".")
+	fmt.Println("Transactions already broadcast keep their nonce on the node.")
+	if confirmTxn("Reset nonce ledger") != nil {
+		fmt.Println("Nonce ledger unchanged")
+		return
+	}
+	address = strings.ToLower(address)
+	err := withNonceLedger(network, func(ledger nonceLedger) error {
+		delete(ledger, address)
+		return nil
+	})
+	if err != nil {
+		fmt.Println("Error:", err)
+		return
+	}
+	fmt.Println("Nonce ledger reset, the next nonce comes from the node")
+}
+
+func formatNonces(nonces []uint64) string {
+	formatted := make([]string, len(nonces))
+	for i, nonce := range nonces {
+		formatted[i] = fmt.Sprint(nonce)
+	}
+	return strings.Join(formatted, ", ")
+}
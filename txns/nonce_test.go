@@ -0,0 +1,153 @@
+// nonce_test.go
+package txns
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+const testSender = "0x9858effd232b4033e47d90003d41ec34ecaeda94"
+
+func TestMergeNonces(t *testing.T) {
+	tests := []struct {
+		name       string
+		nonces     []uint64
+		confirmed  uint64
+		pending    int
+		next       uint64
+		gaps       []uint64
+		duplicates []uint64
+	}{
+		{"empty", nil, 4, 0, 4, nil, nil},
+		{"in a row", []uint64{4, 5, 6}, 4, 3, 7, nil, nil},
+		{"confirmed dropped", []uint64{1, 2, 4, 5}, 4, 2, 6, nil, nil},
+		{"gap filled first", []uint64{4, 6, 8}, 4, 3, 5, []uint64{5, 7}, nil},
+		{"gap at confirmed", []uint64{6}, 4, 1, 4, []uint64{4, 5}, nil},
+		{"duplicates", []uint64{5, 4, 5, 6, 6}, 4, 5, 7, nil, []uint64{5, 6}},
+	}
+	for _, test := range tests {
+		var entries []NonceEntry
+		for _, nonce := range test.nonces {
+			entries = append(entries, NonceEntry{Nonce: nonce, Status: NonceSigned})
+		}
+		state := mergeNonces(entries, test.confirmed)
+		if len(state.Pending) != test.pending || state.Next != test.next ||
+			!reflect.DeepEqual(state.Gaps, test.gaps) || !reflect.DeepEqual(state.Duplicates, test.duplicates) {
+			t.Errorf("%s: pending %d, next %d, gaps %v, duplicates %v, want %d, %d, %v, %v", test.name,
+				len(state.Pending), state.Next, state.Gaps, state.Duplicates, test.pending, test.next, test.gaps, test.duplicates)
+		}
+		for i := 1; i < len(state.Pending); i++ {
+			if state.Pending[i-1].Nonce > state.Pending[i].Nonce {
+				t.Errorf("%s: pending isn't sorted: %v", test.name, state.Pending)
+			}
+		}
+	}
+}
+
+// ledgerEntries reads the ledger entries of testSender
+func ledgerEntries(t *testing.T) []NonceEntry {
+	t.Helper()
+	var entries []NonceEntry
+	err := withNonceLedger("mainnet", func(ledger nonceLedger) error {
+		entries = ledger[testSender]
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return entries
+}
+
+func TestReserveNonce(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	for want := uint64(3); want < 6; want++ {
+		nonce, err := ReserveNonce("mainnet", testSender, 3)
+		if err != nil || nonce != want {
+			t.Fatalf("ReserveNonce = %d, %v, want %d", nonce, err, want)
+		}
+	}
+	// The node confirmed 3 and 4, a freed 5 is handed out again before 6
+	if err := ReleaseNonce("mainnet", testSender, 5, ""); err != nil {
+		t.Fatal(err)
+	}
+	if nonce, err := ReserveNonce("mainnet", testSender, 5); err != nil || nonce != 5 {
+		t.Errorf("ReserveNonce after release = %d, %v, want 5", nonce, err)
+	}
+	if nonce, err := ReserveNonce("mainnet", testSender, 5); err != nil || nonce != 6 {
+		t.Errorf("ReserveNonce = %d, %v, want 6", nonce, err)
+	}
+	if entries := ledgerEntries(t); len(entries) != 2 {
+		t.Errorf("ledger = %v, want nonces 5 and 6", entries)
+	}
+}
+
+func TestReleaseNonceByHash(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	// Two signed transactions share nonce 7, one was broadcast with nonce 8
+	for _, entry := range []NonceEntry{
+		{Nonce: 7, Status: NonceSigned, Hash: "0xaa"},
+		{Nonce: 7, Status: NonceSigned, Hash: "0xbb"},
+		{Nonce: 8, Status: NonceBroadcast, Hash: "0xcc"},
+	} {
+		if err := UpdateNonce("mainnet", testSender, entry.Nonce, entry.Hash, entry.Status); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := ReleaseNonce("mainnet", testSender, 7, "0xAA"); err != nil {
+		t.Fatal(err)
+	}
+	entries := ledgerEntries(t)
+	if len(entries) != 2 || entries[0].Hash != "0xbb" || entries[1].Hash != "0xcc" {
+		t.Errorf("after releasing 0xaa: %v, want 0xbb and 0xcc", entries)
+	}
+	// A broadcast transaction, another hash or an unsigned release keep their entries
+	ReleaseNonce("mainnet", testSender, 8, "0xcc")
+	ReleaseNonce("mainnet", testSender, 7, "0xdd")
+	ReleaseNonce("mainnet", testSender, 7, "")
+	if entries := ledgerEntries(t); len(entries) != 2 {
+		t.Errorf("ledger = %v, want 0xbb and 0xcc kept", entries)
+	}
+}
+
+func TestUpdateNonceTakesOverReservation(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	nonce, err := ReserveNonce("mainnet", testSender, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := UpdateNonce("mainnet", testSender, nonce, "0xaa", NonceSigned); err != nil {
+		t.Fatal(err)
+	}
+	entries := ledgerEntries(t)
+	if len(entries) != 1 || entries[0].Hash != "0xaa" || entries[0].Status != NonceSigned {
+		t.Errorf("ledger = %v, want one signed entry 0xaa", entries)
+	}
+}
+
+func TestDiscardSignedTxn(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	folder := filepath.Join(t.TempDir(), "txn")
+	filename := filepath.Join(folder, "txn.json")
+	if err := os.MkdirAll(folder, 0755); err != nil {
+		t.Fatal(err)
+	}
+	tx := Transaction{Nonce: 2, Sender: testSender, Hash: "0xaa"}
+	txnFile := NewTxnFile(tx, "mainnet", TxnContext{})
+	if err := SaveTxnFile(filename, txnFile); err != nil {
+		t.Fatal(err)
+	}
+	if err := UpdateNonce("mainnet", testSender, 2, "0xaa", NonceSigned); err != nil {
+		t.Fatal(err)
+	}
+	if err := DiscardSignedTxn(txnFile, filename); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(folder); !os.IsNotExist(err) {
+		t.Errorf("folder still exists: %v", err)
+	}
+	if entries := ledgerEntries(t); len(entries) != 0 {
+		t.Errorf("ledger = %v, want the nonce freed", entries)
+	}
+}
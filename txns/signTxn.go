@@ -2,61 +2,30 @@ package txns
 
 import (
 	"encoding/hex"
-	"encoding/json"
 	"fmt"
 	"log"
-	"strconv"
 	"strings"
-	"tbwallet/tbfunctions"
-	"time"
+	"tbwallet/keys"
+	"tbwallet/tbwallet"
 
 	"github.com/ethereum/go-ethereum/crypto"
 )
 
-// SignTxns signs a transaction using the private key derived from the wallet.
-func SignTxn(txSenderAddress, txAmount, txNonce, txReceiverAddress, tx_data string) (bool, map[string]string) {
-	// Retrieve private key from the wallet file
-	privateKeyHex, isKeyFound := tbfunctions.GetPrivateKey()
-	if !isKeyFound {
-		fmt.Println("Private key not found in the wallet file.")
-		return false, nil
-	}
-
-	// Decode the private key from hex (64 chars = 32 bytes)
-	privateKeyBytes, err := hex.DecodeString(privateKeyHex)
+// SignTxn signs the canonical hash of tx, which covers every field including
+// the batch and the fee, with the key at account and index of the wallet.
+func SignTxn(tx Transaction, fromAccount uint32, fromIndex uint32) (bool, Transaction) {
+	// Unlock the signing key from the wallet file
+	privateKey, expectedAddress, err := tbwallet.UnlockSigningKey(fromAccount, fromIndex)
 	if err != nil {
-		fmt.Println("Failed to decode private key:", err)
-		return false, nil
+		fmt.Println("Failed to unlock wallet:", err)
+		return false, tx
 	}
 
-	// Ensure the private key is the correct length (32 bytes)
-	if len(privateKeyBytes) != 32 {
-		fmt.Println("Invalid private key length.")
-		return false, nil
-	}
-	privateKey, err := crypto.ToECDSA(privateKeyBytes)
+	txHash, err := tx.SigningHash()
 	if err != nil {
-		log.Fatalf("Failed to convert private key bytes to ECDSA: %v", err)
-	}
-
-	// Generate the current Unix timestamp
-	txTimestamp := strconv.FormatInt(time.Now().Unix(), 10)
-	transaction := map[string]interface{}{
-		"n": txNonce,           // Nonce
-		"s": txSenderAddress,   // Sender address
-		"r": txReceiverAddress, // Receiver address
-		"t": txTimestamp,       // Timestamp
-		"a": txAmount,          // Amount
-		"b": 0,                 // 0 for even 1 for odd
-		"d": tx_data,
+		fmt.Println("Invalid transaction:", err)
+		return false, tx
 	}
-
-	txn, err := json.Marshal(transaction)
-	if err != nil {
-		return false, nil
-	}
-
-	txHash := crypto.Keccak256Hash(txn)
 	// Sign the transaction hash
 	signature, err := crypto.Sign(txHash.Bytes(), privateKey)
 	if err != nil {
@@ -68,53 +37,14 @@ func SignTxn(txSenderAddress, txAmount, txNonce, txReceiverAddress, tx_data stri
 	if err != nil {
 		log.Fatalf("Failed to recover address: %v", err)
 	}
-	// check batch type
-	config, err := tbfunctions.LoadConfig()
-	if err != nil {
-		fmt.Println(`
-+------------------------------------+
-| Error: Problem with config file    |
-+------------------------------------+
-				`)
-	}
-	TxnBatch := config.TxnBatch
-	result := map[string]string{
-		"n":  txNonce,
-		"s":  txSenderAddress,
-		"r":  txReceiverAddress,
-		"t":  txTimestamp,
-		"a":  txAmount,
-		"b":  TxnBatch,
-		"d":  tx_data,
-		"sg": hex.EncodeToString(signature),
-		"f":  "0000000000",
-	}
-
-	jsonData, err := json.Marshal(result)
-	if err != nil {
-		fmt.Println("Error marshalling map to JSON:", err)
-		return false, nil
-	}
+	tx.Signature = hex.EncodeToString(signature)
+	tx.Hash = txHash.Hex()
 
-	// Get the size of the resulting JSON string
-	transactionSize := len(jsonData)
-	fees := transactionSize * 10
-	result["f"] = strconv.Itoa(fees)
-	result["h"] = txHash.Hex()
-	// Convert the transaction to map[string]string
-	senderAddress = strings.ToLower(senderAddress)
-
-	// Example expected address for verification
-	expectedAddress, isFound := tbfunctions.ShowWalletInfo("address")
-	if !isFound {
-		fmt.Println("Cannot get local wallet address.")
-		return false, nil
-	}
-	if senderAddress == expectedAddress {
-		return true, result
+	if senderAddress == strings.ToLower(expectedAddress) && senderAddress == tx.Sender {
+		return true, tx
 	} else {
 		fmt.Println("Signature verification failed.")
-		return false, nil
+		return false, tx
 	}
 }
 
@@ -130,7 +60,6 @@ func recoverAddress(hash []byte, signature []byte) (string, error) {
 		return "", err
 	}
 
-	// Derive the Ethereum address from the public key
-	address := crypto.PubkeyToAddress(*pubKey)
-	return address.Hex(), nil
+	// Derive the address from the public key the same way the wallet does
+	return keys.Address(pubKey), nil
 }
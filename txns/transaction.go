@@ -0,0 +1,200 @@
+// transaction.go
+package txns
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"tbwallet/keys"
+	"tbwallet/tbfunctions"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// Canonical encoding of a transaction, every integer is big-endian:
+//
+//	magic      4 bytes  "TBTX"
+//	version    1 byte   TxnEncodingVersion
+//	nonce      8 bytes
+//	sender    20 bytes
+//	receiver  20 bytes
+//	timestamp  8 bytes  Unix seconds
+//	amount     8 bytes  Hanas
+//	batch      1 byte   0 hunter, 1 normal
+//	fee        8 bytes  Hanas
+//	data len   4 bytes
+//	data       data len bytes
+//
+// The hash is the Keccak-256 of this encoding and is what the sender signs.
+// A signed transaction is the encoding followed by the 65 byte signature
+// r || s || v. Test vectors are in docs/transaction-encoding.md.
+const (
+	TxnMagic           = "TBTX"
+	TxnEncodingVersion = 1
+	SignatureSize      = 65
+	MaxTxnDataSize     = 1 << 20
+	// Lowest fee rate the node accepts, in Hanas per byte of the signed encoding
+	MinFeePerByte = tbfunctions.MinFeeRate
+)
+
+// Size of the encoding without the data
+const txnHeaderSize = len(TxnMagic) + 1 + 8 + keys.AddressSize*2 + 8 + 8 + 1 + 8 + 4
+
+// Batch types, they match the TxnBatch config values
+const (
+	BatchHunter = 0
+	BatchNormal = 1
+)
+
+// Transaction holds every consensus field of a transaction. The JSON keys and
+// string encoded numbers are the txn.json format.
+type Transaction struct {
+	Nonce     uint64 `json:"n,string"`
+	Sender    string `json:"s"`
+	Receiver  string `json:"r"`
+	Timestamp int64  `json:"t,string"`
+	Amount    uint64 `json:"a,string"`
+	Batch     uint8  `json:"b,string"`
+	Fee       uint64 `json:"f,string"`
+	Data      string `json:"d"`
+	Signature string `json:"sg,omitempty"`
+	Hash      string `json:"h,omitempty"`
+}
+
+// ErrTxnEncoding is returned for bytes that aren't a canonical transaction
+var ErrTxnEncoding = errors.New("invalid transaction encoding")
+
+// parseAddress decodes a 0x hex address into its 20 bytes
+func parseAddress(address string) ([]byte, error) {
+	if !strings.HasPrefix(address, "0x") {
+		return nil, fmt.Errorf("address %q has no 0x prefix", address)
+	}
+	addressBytes, err := hex.DecodeString(address[2:])
+	if err != nil || len(addressBytes) != keys.AddressSize {
+		return nil, fmt.Errorf("invalid address %q", address)
+	}
+	return addressBytes, nil
+}
+
+// Encode returns the canonical encoding of the transaction without the signature
+func (tx Transaction) Encode() ([]byte, error) {
+	sender, err := parseAddress(tx.Sender)
+	if err != nil {
+		return nil, fmt.Errorf("sender: %w", err)
+	}
+	receiver, err := parseAddress(tx.Receiver)
+	if err != nil {
+		return nil, fmt.Errorf("receiver: %w", err)
+	}
+	if tx.Batch != BatchHunter && tx.Batch != BatchNormal {
+		return nil, fmt.Errorf("invalid batch %d", tx.Batch)
+	}
+	if len(tx.Data) > MaxTxnDataSize {
+		return nil, fmt.Errorf("data is %d bytes, the limit is %d", len(tx.Data), MaxTxnDataSize)
+	}
+
+	encoded := make([]byte, 0, txnHeaderSize+len(tx.Data))
+	encoded = append(encoded, TxnMagic...)
+	encoded = append(encoded, TxnEncodingVersion)
+	encoded = binary.BigEndian.AppendUint64(encoded, tx.Nonce)
+	encoded = append(encoded, sender...)
+	encoded = append(encoded, receiver...)
+	encoded = binary.BigEndian.AppendUint64(encoded, uint64(tx.Timestamp))
+	encoded = binary.BigEndian.AppendUint64(encoded, tx.Amount)
+	encoded = append(encoded, tx.Batch)
+	encoded = binary.BigEndian.AppendUint64(encoded, tx.Fee)
+	encoded = binary.BigEndian.AppendUint32(encoded, uint32(len(tx.Data)))
+	encoded = append(encoded, tx.Data...)
+	return encoded, nil
+}
+
+// EncodeSigned returns the canonical encoding followed by the signature
+func (tx Transaction) EncodeSigned() ([]byte, error) {
+	encoded, err := tx.Encode()
+	if err != nil {
+		return nil, err
+	}
+	signature, err := hex.DecodeString(tx.Signature)
+	if err != nil || len(signature) != SignatureSize {
+		return nil, fmt.Errorf("invalid signature %q", tx.Signature)
+	}
+	return append(encoded, signature...), nil
+}
+
+// DecodeTransaction parses an unsigned or signed canonical encoding. Addresses
+// come back as lower case 0x hex and Hash is set for signed transactions.
+func DecodeTransaction(encoded []byte) (Transaction, error) {
+	var tx Transaction
+	if len(encoded) < txnHeaderSize || !bytes.HasPrefix(encoded, []byte(TxnMagic)) {
+		return tx, ErrTxnEncoding
+	}
+	if encoded[4] != TxnEncodingVersion {
+		return tx, fmt.Errorf("%w: unknown version %d", ErrTxnEncoding, encoded[4])
+	}
+	rest := encoded[5:]
+	next := func(n int) []byte {
+		field := rest[:n]
+		rest = rest[n:]
+		return field
+	}
+	tx.Nonce = binary.BigEndian.Uint64(next(8))
+	tx.Sender = "0x" + hex.EncodeToString(next(keys.AddressSize))
+	tx.Receiver = "0x" + hex.EncodeToString(next(keys.AddressSize))
+	tx.Timestamp = int64(binary.BigEndian.Uint64(next(8)))
+	tx.Amount = binary.BigEndian.Uint64(next(8))
+	tx.Batch = next(1)[0]
+	tx.Fee = binary.BigEndian.Uint64(next(8))
+	dataSize := binary.BigEndian.Uint32(next(4))
+	if tx.Batch != BatchHunter && tx.Batch != BatchNormal {
+		return tx, fmt.Errorf("%w: invalid batch %d", ErrTxnEncoding, tx.Batch)
+	}
+	if dataSize > MaxTxnDataSize || uint64(len(rest)) < uint64(dataSize) {
+		return tx, fmt.Errorf("%w: data length %d", ErrTxnEncoding, dataSize)
+	}
+	tx.Data = string(next(int(dataSize)))
+
+	switch len(rest) {
+	case 0:
+	case SignatureSize:
+		tx.Signature = hex.EncodeToString(rest)
+		hash, err := tx.SigningHash()
+		if err != nil {
+			return tx, err
+		}
+		tx.Hash = hash.Hex()
+	default:
+		return tx, fmt.Errorf("%w: %d trailing bytes", ErrTxnEncoding, len(rest))
+	}
+	return tx, nil
+}
+
+// SigningHash returns the Keccak-256 hash of the canonical encoding
+func (tx Transaction) SigningHash() (common.Hash, error) {
+	encoded, err := tx.Encode()
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return crypto.Keccak256Hash(encoded), nil
+}
+
+// SignedSize is the size in bytes of the signed encoding, the fee is charged on it
+func (tx Transaction) SignedSize() int {
+	return txnHeaderSize + len(tx.Data) + SignatureSize
+}
+
+// FeeAt is the fee in Hanas for the signed size of the transaction at rate
+// Hanas per byte. The fee field has a fixed width, so the fee can be set
+// before signing.
+func (tx Transaction) FeeAt(rate uint64) uint64 {
+	return uint64(tx.SignedSize()) * rate
+}
+
+// RequiredFee is the lowest fee the node accepts for the transaction
+func (tx Transaction) RequiredFee() uint64 {
+	return tx.FeeAt(MinFeePerByte)
+}
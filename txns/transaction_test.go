@@ -0,0 +1,165 @@
+// transaction_test.go
+package txns
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// Test vectors of docs/transaction-encoding.md
+const (
+	vectorPrivateKey = "4646464646464646464646464646464646464646464646464646464646464646"
+	vectorAddress    = "0x9d8a62f656a8d1615c1294fd71e9cfb3e4855a4f"
+)
+
+var encodingVectors = []struct {
+	name       string
+	txnJSON    string
+	encoding   string
+	hash       string
+	signature  string
+	signedSize int
+	fee        uint64
+}{
+	{
+		name:       "normal batch with data",
+		txnJSON:    `{"n":"0","s":"0x9d8a62f656a8d1615c1294fd71e9cfb3e4855a4f","r":"0x6fac4d18c912343bf86fa7049364dd4e424ab9c0","t":"1700000000","a":"100","b":"1","f":"1490","d":"hi"}`,
+		encoding:   "544254580100000000000000009d8a62f656a8d1615c1294fd71e9cfb3e4855a4f6fac4d18c912343bf86fa7049364dd4e424ab9c0000000006553f10000000000000000640100000000000005d2000000026869",
+		hash:       "0x88ec3d4feaab3ad0ae51d0db84e0991f00ba61c4ee835bdc5bd1abd2ebf6a9ef",
+		signature:  "453162ea97fdf2d9e868cbe231dbbddc098d3b4250cf3a5bceda9fc64953452077f337da2f95e4ef273470eb1df62c4cf4f420807b23e7de01d1c0345aba6c0600",
+		signedSize: 149,
+		fee:        1490,
+	},
+	{
+		name:       "hunter batch without data",
+		txnJSON:    `{"n":"7","s":"0x9d8a62f656a8d1615c1294fd71e9cfb3e4855a4f","r":"0x9858effd232b4033e47d90003d41ec34ecaeda94","t":"1735689600","a":"10000000","b":"0","f":"1470","d":""}`,
+		encoding:   "544254580100000000000000079d8a62f656a8d1615c1294fd71e9cfb3e4855a4f9858effd232b4033e47d90003d41ec34ecaeda94000000006774858000000000009896800000000000000005be00000000",
+		hash:       "0x13321e6d031d27b15a758a78a8d3c8f7b84c1e63ab4b5322a47b7fa5856878f5",
+		signature:  "2454604d74d56fdf69e107f4c1e7d8c2365d5a2a02c9fede05843b3af10e59955f799b83c72672d793573b0b2f45b038aee522ce111ea8f9ac84aa5bef6d363d00",
+		signedSize: 147,
+		fee:        1470,
+	},
+}
+
+func TestEncodingVectors(t *testing.T) {
+	privateKey, err := crypto.HexToECDSA(vectorPrivateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, vector := range encodingVectors {
+		var tx Transaction
+		if err := json.Unmarshal([]byte(vector.txnJSON), &tx); err != nil {
+			t.Fatalf("%s: %v", vector.name, err)
+		}
+		encoded, err := tx.Encode()
+		if err != nil {
+			t.Fatalf("%s: Encode: %v", vector.name, err)
+		}
+		if got := hex.EncodeToString(encoded); got != vector.encoding {
+			t.Errorf("%s: Encode = %s, want %s", vector.name, got, vector.encoding)
+		}
+		hash, err := tx.SigningHash()
+		if err != nil {
+			t.Fatalf("%s: SigningHash: %v", vector.name, err)
+		}
+		if hash.Hex() != vector.hash {
+			t.Errorf("%s: SigningHash = %s, want %s", vector.name, hash.Hex(), vector.hash)
+		}
+
+		// RFC 6979 signatures are deterministic
+		signature, err := crypto.Sign(hash.Bytes(), privateKey)
+		if err != nil {
+			t.Fatalf("%s: Sign: %v", vector.name, err)
+		}
+		if got := hex.EncodeToString(signature); got != vector.signature {
+			t.Errorf("%s: signature = %s, want %s", vector.name, got, vector.signature)
+		}
+		sender, err := recoverAddress(hash.Bytes(), signature)
+		if err != nil || sender != vectorAddress {
+			t.Errorf("%s: recoverAddress = %s, %v, want %s", vector.name, sender, err, vectorAddress)
+		}
+
+		if got := tx.SignedSize(); got != vector.signedSize {
+			t.Errorf("%s: SignedSize = %d, want %d", vector.name, got, vector.signedSize)
+		}
+		if got := tx.RequiredFee(); got != vector.fee || tx.Fee != vector.fee {
+			t.Errorf("%s: RequiredFee = %d, fee field %d, want %d", vector.name, got, tx.Fee, vector.fee)
+		}
+
+		tx.Signature = vector.signature
+		signed, err := tx.EncodeSigned()
+		if err != nil {
+			t.Fatalf("%s: EncodeSigned: %v", vector.name, err)
+		}
+		if got := hex.EncodeToString(signed); got != vector.encoding+vector.signature {
+			t.Errorf("%s: EncodeSigned = %s", vector.name, got)
+		}
+		if len(signed) != vector.signedSize {
+			t.Errorf("%s: len(EncodeSigned) = %d, want %d", vector.name, len(signed), vector.signedSize)
+		}
+	}
+}
+
+func TestDecodeRoundTrip(t *testing.T) {
+	for _, vector := range encodingVectors {
+		var tx Transaction
+		if err := json.Unmarshal([]byte(vector.txnJSON), &tx); err != nil {
+			t.Fatalf("%s: %v", vector.name, err)
+		}
+		encoded, err := tx.Encode()
+		if err != nil {
+			t.Fatal(err)
+		}
+		decoded, err := DecodeTransaction(encoded)
+		if err != nil {
+			t.Fatalf("%s: DecodeTransaction(unsigned): %v", vector.name, err)
+		}
+		if decoded != tx {
+			t.Errorf("%s: DecodeTransaction(unsigned) = %+v, want %+v", vector.name, decoded, tx)
+		}
+
+		tx.Signature = vector.signature
+		tx.Hash = vector.hash
+		signed, err := tx.EncodeSigned()
+		if err != nil {
+			t.Fatal(err)
+		}
+		decoded, err = DecodeTransaction(signed)
+		if err != nil {
+			t.Fatalf("%s: DecodeTransaction(signed): %v", vector.name, err)
+		}
+		if decoded != tx {
+			t.Errorf("%s: DecodeTransaction(signed) = %+v, want %+v", vector.name, decoded, tx)
+		}
+	}
+}
+
+func TestDecodeTransactionErrors(t *testing.T) {
+	valid, err := hex.DecodeString(encodingVectors[0].encoding)
+	if err != nil {
+		t.Fatal(err)
+	}
+	withByte := func(index int, value byte) []byte {
+		encoded := append([]byte(nil), valid...)
+		encoded[index] = value
+		return encoded
+	}
+	tests := map[string][]byte{
+		"empty":          nil,
+		"short header":   valid[:txnHeaderSize-1],
+		"magic":          withByte(0, 'X'),
+		"version":        withByte(4, 2),
+		"batch":          withByte(txnHeaderSize-13, 2),
+		"truncated data": valid[:len(valid)-1],
+		"trailing bytes": append(append([]byte(nil), valid...), 0x00),
+	}
+	for name, encoded := range tests {
+		if _, err := DecodeTransaction(encoded); !errors.Is(err, ErrTxnEncoding) {
+			t.Errorf("DecodeTransaction(%s) error = %v, want ErrTxnEncoding", name, err)
+		}
+	}
+}
@@ -0,0 +1,169 @@
+// txnFile.go
+package txns
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"strconv"
+	"time"
+
+	"tbwallet/amounts"
+	"tbwallet/tbfunctions"
+)
+
+// Transaction file format written by txn build and txn sign
+const (
+	TxnFileFormat  = "tbwallet-txn"
+	TxnFileVersion = 1
+)
+
+// Status of a transaction file
+const (
+	TxnStatusUnsigned = "unsigned"
+	TxnStatusSigned   = "signed"
+)
+
+// TxnContext is what the online machine knew when it built the transaction.
+// It is shown on the signing review and isn't covered by the signature.
+type TxnContext struct {
+	Balance        uint64 `json:"balance,string"`
+	Account        uint32 `json:"account"`
+	Index          uint32 `json:"index"`
+	RecipientLabel string `json:"recipient_label,omitempty"`
+	FeeTier        string `json:"fee_tier,omitempty"`
+}
+
+// TxnFile is a transaction moved between the online and the offline machine
+type TxnFile struct {
+	Format      string      `json:"format"`
+	Version     int         `json:"version"`
+	Description string      `json:"description"`
+	Network     string      `json:"network"`
+	Status      string      `json:"status"`
+	Context     TxnContext  `json:"context"`
+	Transaction Transaction `json:"transaction"`
+}
+
+// NewTxnFile wraps an unsigned transaction for the network
+func NewTxnFile(tx Transaction, network string, context TxnContext) TxnFile {
+	return TxnFile{
+		Format:      TxnFileFormat,
+		Version:     TxnFileVersion,
+		Description: "Unsigned Tulobyte transaction, sign it offline with: tbwallet txn sign <file>",
+		Network:     network,
+		Status:      TxnStatusUnsigned,
+		Context:     context,
+		Transaction: tx,
+	}
+}
+
+// LoadTxnFile reads a transaction file and checks its format and version
+func LoadTxnFile(filename string) (TxnFile, error) {
+	var txnFile TxnFile
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return txnFile, err
+	}
+	err = json.Unmarshal(data, &txnFile)
+	if err != nil {
+		return txnFile, fmt.Errorf("invalid transaction file: %w", err)
+	}
+	if txnFile.Format != TxnFileFormat {
+		return txnFile, fmt.Errorf("not a %s file", TxnFileFormat)
+	}
+	if txnFile.Version != TxnFileVersion {
+		return txnFile, fmt.Errorf("unsupported transaction file version %d, this tbwallet reads version %d", txnFile.Version, TxnFileVersion)
+	}
+	if txnFile.Status != TxnStatusUnsigned && txnFile.Status != TxnStatusSigned {
+		return txnFile, fmt.Errorf("unknown transaction file status %q", txnFile.Status)
+	}
+	if _, err := txnFile.Transaction.Encode(); err != nil {
+		return txnFile, err
+	}
+	return txnFile, nil
+}
+
+// SaveTxnFile writes a transaction file
+func SaveTxnFile(filename string, txnFile TxnFile) error {
+	var data bytes.Buffer
+	encoder := json.NewEncoder(&data)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	err := encoder.Encode(txnFile)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filename, data.Bytes(), 0644)
+}
+
+// checkTxnNetwork rejects transaction files of another network than the config
+func checkTxnNetwork(txnFile TxnFile) error {
+	config, err := tbfunctions.LoadConfig()
+	if err != nil {
+		return fmt.Errorf("error loading config: %w", err)
+	}
+	if txnFile.Network != config.Network {
+		return fmt.Errorf("transaction is for %s but the wallet is on %s", txnFile.Network, config.Network)
+	}
+	return nil
+}
+
+// PrintTxnReview prints every field of the transaction for the signer to check
+func PrintTxnReview(txnFile TxnFile) {
+	tx := txnFile.Transaction
+	recipient := tbfunctions.DisplayAddress(tx.Receiver)
+	if txnFile.Context.RecipientLabel != "" {
+		recipient = "@" + txnFile.Context.RecipientLabel + " (" + recipient + ")"
+	}
+	batch := "Normal"
+	if tx.Batch == BatchHunter {
+		batch = "Hunter"
+	}
+	data := tx.Data
+	if len(data) > 64 {
+		data = data[:64] + "... (" + strconv.Itoa(len(tx.Data)) + " bytes)"
+	}
+
+	fmt.Println(`
+  +-----------------------------------+
+  |  Review Transaction               |
+  +-----------------------------------+`)
+	fmt.Println()
+	fmt.Println("  Network   : " + txnFile.Network)
+	fmt.Println("  From      : " + tbfunctions.DisplayAddress(tx.Sender))
+	fmt.Printf("              account %d, index %d\n", txnFile.Context.Account, txnFile.Context.Index)
+	fmt.Println("  To        : " + recipient)
+	unit := tbfunctions.DisplayUnit()
+	fmt.Println("  Amount    : " + amounts.FormatUint(tx.Amount, unit))
+	feeRate := fmt.Sprintf("%d Hanas per byte", tx.Fee/uint64(tx.SignedSize()))
+	if txnFile.Context.FeeTier != "" {
+		feeRate = txnFile.Context.FeeTier + " tier, " + feeRate
+	}
+	fmt.Printf("  Fees      : %s for %d bytes (%s)\n", amounts.FormatUint(tx.Fee, unit), tx.SignedSize(), feeRate)
+	total := new(big.Int).Add(new(big.Int).SetUint64(tx.Amount), new(big.Int).SetUint64(tx.Fee))
+	fmt.Println("  Total     : " + amounts.Format(total, unit))
+	fmt.Println("  Balance   : " + amounts.FormatUint(txnFile.Context.Balance, unit) + " when built")
+	fmt.Printf("  Nonce     : %d\n", tx.Nonce)
+	fmt.Println("  Batch     : " + batch)
+	fmt.Println("  Timestamp : " + time.Unix(tx.Timestamp, 0).UTC().Format(time.RFC3339))
+	fmt.Println("  Data      : " + data)
+	fmt.Println()
+}
+
+// errTxnDeclined is returned when the user doesn't confirm a prompt
+var errTxnDeclined = errors.New("declined")
+
+// confirmTxn asks a Y/N question, anything but Y or y declines
+func confirmTxn(question string) error {
+	var answer string
+	fmt.Print("  " + question + " (Y/N): ")
+	fmt.Scanln(&answer)
+	if answer != "Y" && answer != "y" {
+		return errTxnDeclined
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package txns
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"tbwallet/tbfunctions"
+	"time"
+)
+
+// BuildUnsignedTxn assembles the transaction SignTxn signs, with the batch of
+// the config and the fee for its size at feeRate Hanas per byte. Watch-only
+// wallets hand it to an offline signer.
+func BuildUnsignedTxn(txSenderAddress, txAmount, txNonce, txReceiverAddress, tx_data string, feeRate uint64) (Transaction, error) {
+	config, err := tbfunctions.LoadConfig()
+	if err != nil {
+		return Transaction{}, fmt.Errorf("error loading config: %w", err)
+	}
+	nonce, err := strconv.ParseUint(txNonce, 10, 64)
+	if err != nil {
+		return Transaction{}, fmt.Errorf("invalid nonce %q", txNonce)
+	}
+	amount, err := strconv.ParseUint(txAmount, 10, 64)
+	if err != nil {
+		return Transaction{}, fmt.Errorf("invalid amount %q", txAmount)
+	}
+	tx := Transaction{
+		Nonce:     nonce,
+		Sender:    strings.ToLower(txSenderAddress),
+		Receiver:  strings.ToLower(txReceiverAddress),
+		Timestamp: time.Now().Unix(),
+		Amount:    amount,
+		Batch:     ConfigBatch(config),
+		Data:      tx_data,
+	}
+	tx.Fee = tx.FeeAt(feeRate)
+	_, err = tx.Encode()
+	if err != nil {
+		return Transaction{}, err
+	}
+	return tx, nil
+}
+
+// ConfigBatch returns the batch type of the TxnBatch config value, "0" is hunter
+// and anything else normal
+func ConfigBatch(config tbfunctions.Config) uint8 {
+	if config.TxnBatch == "0" {
+		return BatchHunter
+	}
+	return BatchNormal
+}
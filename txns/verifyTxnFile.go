@@ -0,0 +1,118 @@
+// verifyTxnFile.go
+package txns
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"tbwallet/amounts"
+	"tbwallet/tbfunctions"
+)
+
+// txnCheck is one line of the txn verify report
+type txnCheck struct {
+	name   string
+	passed bool
+	detail string
+}
+
+// checkTxn audits a signed transaction without the wallet or a node: the
+// encoding, the hash, the signer and the fee. It returns every check so the
+// caller can print a full report.
+func checkTxn(tx Transaction) []txnCheck {
+	var checks []txnCheck
+	encoded, err := tx.Encode()
+	if err != nil {
+		return append(checks, txnCheck{"Encoding", false, err.Error()})
+	}
+	checks = append(checks, txnCheck{"Encoding", true, strconv.Itoa(len(encoded)) + " bytes"})
+
+	hash, _ := tx.SigningHash()
+	if strings.EqualFold(tx.Hash, hash.Hex()) {
+		checks = append(checks, txnCheck{"Hash", true, hash.Hex()})
+	} else {
+		checks = append(checks, txnCheck{"Hash", false, "file has " + tx.Hash + ", fields hash to " + hash.Hex()})
+	}
+
+	signature, err := hex.DecodeString(tx.Signature)
+	if err != nil {
+		checks = append(checks, txnCheck{"Signer", false, "signature is not hex"})
+	} else if signer, err := recoverAddress(hash.Bytes(), signature); err != nil {
+		checks = append(checks, txnCheck{"Signer", false, err.Error()})
+	} else if signer != strings.ToLower(tx.Sender) {
+		checks = append(checks, txnCheck{"Signer", false, "signed by " + tbfunctions.DisplayAddress(signer) + ", not the sender"})
+	} else {
+		checks = append(checks, txnCheck{"Signer", true, tbfunctions.DisplayAddress(signer)})
+	}
+
+	requiredFee := tx.RequiredFee()
+	unit := tbfunctions.DisplayUnit()
+	feeDetail := fmt.Sprintf("%s for %d bytes, %s required", amounts.FormatUint(tx.Fee, unit), tx.SignedSize(), amounts.FormatUint(requiredFee, unit))
+	checks = append(checks, txnCheck{"Fee", tx.Fee >= requiredFee, feeDetail})
+	return checks
+}
+
+// VerifyTxnFile prints the checkTxn report of a txn.json file and reports
+// whether every check passed
+func VerifyTxnFile(filename string) bool {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		fmt.Println("Error reading transaction file:", err)
+		return false
+	}
+	// Files of txn build and txn sign wrap the transaction, older txn.json files are the bare transaction
+	var txnFile TxnFile
+	err = json.Unmarshal(data, &txnFile)
+	tx := txnFile.Transaction
+	nonceUnknown := false
+	if err == nil && txnFile.Format == "" {
+		tx, nonceUnknown, err = decodeLegacyTxn(data)
+	} else if err == nil {
+		txnFile, err = LoadTxnFile(filename)
+		tx = txnFile.Transaction
+	}
+	if err != nil {
+		fmt.Println(`
++--------------------------------------------+
+| Error: Transaction file can't be read      |
++--------------------------------------------+
+  Reason: ` + err.Error())
+		return false
+	}
+
+	fmt.Println("Transaction : " + filename)
+	fmt.Println("Sender      : " + tbfunctions.DisplayAddress(tx.Sender))
+	fmt.Println("Recipient   : " + tbfunctions.DisplayAddress(tx.Receiver))
+	fmt.Println("Amount      : " + amounts.FormatUint(tx.Amount, tbfunctions.DisplayUnit()))
+	if nonceUnknown {
+		fmt.Println("Nonce       : unknown, the file has nonce " + legacyUnknownNonce + ", the node gave none when it was signed")
+	} else {
+		fmt.Printf("Nonce       : %d\n", tx.Nonce)
+	}
+	fmt.Println()
+
+	allPassed := true
+	for _, check := range checkTxn(tx) {
+		status := "PASS"
+		if !check.passed {
+			status = "FAIL"
+			allPassed = false
+		}
+		fmt.Printf("  [%s] %-9s %s\n", status, check.name, check.detail)
+	}
+	if allPassed {
+		fmt.Println(`
++------------------------------------+
+| Success: Transaction is valid      |
++------------------------------------+`)
+	} else {
+		fmt.Println(`
++------------------------------------+
+| Error: Transaction failed checks   |
++------------------------------------+`)
+	}
+	return allPassed
+}
@@ -1,79 +1,70 @@
 package txns
 
 import (
+	"context"
 	"fmt"
 	"log"
+	"math/big"
 	"os"
 	"path/filepath"
 	"strconv"
 	"strings"
+	"tbwallet/amounts"
+	"tbwallet/rpcclient"
 	"tbwallet/tbfunctions"
+	"tbwallet/tbwallet"
 )
 
-func VerifyTxn(rec_address string, tx_data string, amount_hb int) (bool, string, map[string]string) {
-	var tx_nonce int
+func VerifyTxn(rec_address string, tx_data string, amount_hb uint64, fromAccount uint32, fromIndex uint32) (bool, string, map[string]string) {
 	var tx_amount = amount_hb
 	var tx_raddress = rec_address
-	aval_amount := 0
-	addressVerified, txns, amt, networkType := VerifyAddress(rec_address)
+	tx_sAddress, tx_publicKey, err := tbwallet.WalletAddress(fromAccount, fromIndex)
+	if err != nil {
+		fmt.Println("Error:", err)
+		return false, "", nil
+	}
+	addressVerified, txns, amt, networkType := VerifyAddress(rec_address, tx_sAddress)
 	if !addressVerified {
 		return false, "", nil
 	}
 
-	aval_amount = amt
-	if amount_hb > aval_amount {
+	aval_amount := amt
+	if new(big.Int).SetUint64(amount_hb).Cmp(aval_amount) > 0 {
+		unit := tbfunctions.DisplayUnit()
 		returnError := `
 +----------------------------------------+
 | Error:  Insufficient TBYT Balance      |
 | Reason: Transfer amount is larger then |
 |         available balance              |
 +----------------------------------------+
-					`
+  Amount  : ` + amounts.FormatUint(amount_hb, unit) + `
+  Balance : ` + amounts.Format(aval_amount, unit)
 		return false, returnError, nil
 	}
-	tx_sAddress, isFound := tbfunctions.ShowWalletInfo("address")
-	if !isFound {
-		return false, "", nil
-	}
-	tx_publicKey, isFound := tbfunctions.ShowWalletInfo("pubkey")
-	if !isFound {
-		return false, "", nil
-	}
-	tx_privateKey, isFound := tbfunctions.ShowWalletInfo("privatekey")
-	if !isFound {
-		return false, "", nil
-	}
 	isCreated, txnFolder := CreateTxnsDirs(networkType)
 	if !isCreated {
 		return false, "", nil
 	}
-	if txns == 0 {
-		tx_nonce = 0
-	} else {
-		tx_nonce = txns - 1
+	// The node counts the confirmed transactions, the ledger adds the pending ones
+	tx_nonce, err := ReserveNonce(networkType, tx_sAddress, txns)
+	if err != nil {
+		fmt.Println("Error reserving a nonce:", err)
+		return false, "", nil
 	}
 	inputs := map[string]string{
-		"txnFolder":     txnFolder,
-		"networkType":   networkType,
-		"tx_sAddress":   tx_sAddress,
-		"tx_raddress":   tx_raddress,
-		"tx_publicKey":  tx_publicKey,
-		"tx_privateKey": tx_privateKey,
-		"tx_amount":     strconv.Itoa(tx_amount),
-		"tx_nonce":      strconv.Itoa(tx_nonce),
-		"tx_data":       tx_data,
+		"txnFolder":    txnFolder,
+		"networkType":  networkType,
+		"tx_sAddress":  tx_sAddress,
+		"tx_raddress":  tx_raddress,
+		"tx_publicKey": tx_publicKey,
+		"tx_amount":    strconv.FormatUint(tx_amount, 10),
+		"tx_nonce":     strconv.FormatUint(tx_nonce, 10),
+		"tx_data":      tx_data,
+		"balance":      aval_amount.String(),
 	}
 	return true, "", inputs
 }
 
-func CalculateFastTxnSize(tx_publicKey string, tx_sAddress string, tx_amount string, tx_nonce string, tx_raddress string) (int, bool) {
-	return 0, true
-}
-
-func CalculateSlowTxnSize(tx_publicKey string, tx_sAddress string, tx_amount string, tx_nonce string, tx_raddress string, tx_image string) (int, bool) {
-	return 0, true
-}
-
 func CreateTxnsDirs(networkType string) (bool, string) {
 	dirsInitiliazed := tbfunctions.InitDirs(false)
 	if !dirsInitiliazed {
@@ -87,13 +78,19 @@ func CreateTxnsDirs(networkType string) (bool, string) {
 	noOfFolder := 0
 	homeDir, err := os.UserHomeDir()
 
-	// Create tulobyte directory in ~/.config/tulobyte
 	if err != nil {
 		fmt.Println("Can't get user home directory")
 		log.Fatalf("   Reason: %v", err)
 		return false, ""
 	}
-	txnDir := filepath.Join(homeDir, "tulobyte", networkType, "txns")
+	// InitDirs creates ~/tbwallet/<network>, the txns folder is created on first use
+	txnDir := filepath.Join(homeDir, "tbwallet", networkType, "txns")
+	err = os.MkdirAll(txnDir, 0755)
+	if err != nil {
+		fmt.Println("Can't create '", txnDir, "'")
+		log.Fatalf("   Reason: %v", err)
+		return false, ""
+	}
 	files, err := os.ReadDir(txnDir)
 	if err != nil {
 		fmt.Println("Failed to read '", txnDir, "'")
@@ -121,14 +118,16 @@ func CreateTxnsDirs(networkType string) (bool, string) {
 	return true, newTxDir
 }
 
-func VerifyAddress(rec_address string) (bool, int, int, string) {
+// VerifyAddress checks the recipient address format and returns the transaction
+// count and balance of the sender from the node of the configured network
+func VerifyAddress(rec_address string, sender string) (bool, uint64, *big.Int, string) {
 	if !VerifyAddressFormat(rec_address) {
 		fmt.Println(`
 +-----------------------------------+
 | Error: Invalid Recipent Address   |
 +-----------------------------------+
 					`)
-		return false, 0, 0, ""
+		return false, 0, nil, ""
 	}
 	// check network type
 	config, err := tbfunctions.LoadConfig()
@@ -138,67 +137,65 @@ func VerifyAddress(rec_address string) (bool, int, int, string) {
 | Error: Problem with config file    |
 +------------------------------------+
 			`)
-		return false, 0, 0, ""
+		return false, 0, nil, ""
 	}
 	networkType := config.Network
-	if networkType == "mainnet" {
-		amt, txns, walletChecked := CheckMyWalletMainnet()
-		if !walletChecked {
-			fmt.Println(`
-+-----------------------------------------------------------+
-| Error: Can't query RPC node to get wallet Transactions    |
-+-----------------------------------------------------------+
-						`)
-			return false, 0, 0, ""
-		} else {
-			return true, txns, amt, networkType
-		}
-	} else if networkType == "testnet" {
-		amt, txns, walletChecked := CheckMyWalletTestnet()
-		if !walletChecked {
-			fmt.Println(`
+	if _, ok := tbfunctions.NetworkHRPs[networkType]; !ok {
+		return false, 0, nil, ""
+	}
+	amt, txns, err := CheckMyWallet(networkType, sender)
+	if err != nil {
+		fmt.Println(`
 +-----------------------------------------------------------+
 | Error: Can't query RPC node to get wallet Transactions    |
 +-----------------------------------------------------------+
-						`)
-			return false, 0, 0, ""
-		} else {
-			return true, txns, amt, networkType
-		}
-	} else {
-		return false, 0, 0, ""
+  Node   : ` + config.RPCURL(networkType) + `
+  Reason : ` + err.Error())
+		return false, 0, nil, ""
 	}
-
+	return true, txns, amt, networkType
 }
+
 func VerifyAmount(amount_hb int) bool { return true }
 
+// VerifyAddressFormat reports whether rec_address is 0x followed by 40 hex characters
 func VerifyAddressFormat(rec_address string) bool {
-	returnValue := true
 	// Check if the address has the correct length (42 characters including '0x' prefix)
 	if len(rec_address) != 42 {
-		returnValue = false
+		return false
 	}
 
 	// Check if the address starts with '0x'
 	if !strings.HasPrefix(rec_address, "0x") {
-		returnValue = false
+		return false
 	}
 
 	// Check if the address contains only valid hexadecimal characters
 	for _, char := range rec_address[2:] {
 		if !strings.Contains("0123456789abcdefABCDEF", string(char)) {
-			returnValue = false
+			return false
 		}
 	}
-
-	return returnValue
+	return true
 }
 
-func CheckMyWalletMainnet() (int, int, bool) {
-	return 10000, 0, true
-}
-func CheckMyWalletTestnet() (int, int, bool) {
-	return 10000, 0, true
+// CheckMyWallet returns the confirmed balance in Hanas and the transaction
+// count of address from the node of network
+func CheckMyWallet(network string, address string) (*big.Int, uint64, error) {
+	client, err := tbfunctions.NodeClient(network)
+	if err != nil {
+		return nil, 0, err
+	}
+	ctx := context.Background()
+	balance, err := client.GetBalance(ctx, address, rpcclient.TagLatest)
+	if err != nil {
+		return nil, 0, err
+	}
+	count, err := client.GetTransactionCount(ctx, address, rpcclient.TagLatest)
+	if err != nil {
+		return nil, 0, err
+	}
+	return balance, count, nil
 }
 
 // convertImageToBase64 converts an image file (including WebP) to a Base64 string.
@@ -2,20 +2,56 @@ package txns
 
 import (
 	"fmt"
-	"os"
 	"strconv"
 	"strings"
+	"tbwallet/amounts"
+	"tbwallet/keys"
 	"tbwallet/tbfunctions"
+	"tbwallet/tbwallet"
 )
 
-func VerifyTxnInputs() (string, bool, map[string]string) {
+func VerifyTxnInputs(fromAccount uint32, fromIndex uint32) (string, bool, map[string]string) {
 	argsReq := 5
+	args := tbfunctions.PositionalArgs("--account", "--from-index", "--out", "--fee-tier", "--fee-rate", "--max-fee", "--unit")
+	if len(args) > 2 && args[2] == "build" {
+		// txn build takes the same arguments as txn
+		args = append(args[:2], args[3:]...)
+	}
 
-	if len(os.Args) == argsReq {
+	if len(args) == argsReq {
 		var tx_data string
-		rec_address := os.Args[2]
-		localAddress, isFound := tbfunctions.ShowWalletInfo("address")
-		if isFound {
+		rec_address := args[2]
+		rec_label := ""
+		if strings.HasPrefix(rec_address, "@") {
+			// Contacts were checked when they were added
+			contact, err := tbfunctions.ResolveContact(rec_address)
+			if err != nil {
+				returnError := `
++-------------------------------------------+
+| Error: Unknown Recipient Contact          |
++-------------------------------------------+
+  Reason: ` + err.Error()
+				return returnError, false, nil
+			}
+			rec_address = contact.Address
+			rec_label = contact.Label
+		} else if tbfunctions.IsBech32Address(rec_address) {
+			// Bech32m carries its own checksum and network prefix
+			hexAddress, err := tbfunctions.ParseBech32Address(rec_address)
+			if err != nil {
+				returnError := `
++-------------------------------------------+
+| Error: Invalid Bech32 Recipient Address   |
++-------------------------------------------+
+  Reason: ` + err.Error()
+				return returnError, false, nil
+			}
+			rec_address = hexAddress
+		} else if returnError, isValid := CheckAddressChecksum(rec_address); !isValid {
+			return returnError, false, nil
+		}
+		localAddress, _, err := tbwallet.WalletAddress(fromAccount, fromIndex)
+		if err == nil {
 			rec_address = strings.ToLower(rec_address)
 			localAddress = strings.ToLower(localAddress)
 			if localAddress == rec_address {
@@ -34,36 +70,98 @@ func VerifyTxnInputs() (string, bool, map[string]string) {
 								`
 			return returnError, false, nil
 		}
-		amount_hb, amount_error := strconv.Atoi(os.Args[3])
+		amount_hb, amount_error := parseTxnAmount(args[3])
 		if amount_error != nil {
 			returnError := `
-+---------------------------------------+
-| Error: Please enter amount in digits  |
-|        e.g 100, 23, 1000 etc          |
-+---------------------------------------+
-					`
++---------------------------------------------------+
+| Error: Invalid amount, enter e.g 1.5TBYT,         |
+|        1500000hanas or 100                        |
++---------------------------------------------------+
+  Reason: ` + amount_error.Error()
 			return returnError, false, nil
 		}
-		tx_data = os.Args[4]
+		tx_data = args[4]
 		verifiedInput := CheckInputErrors(rec_address, amount_hb)
 		if verifiedInput { // Inputs have no problem
 
 			inputs := map[string]string{
 				"rec_address": rec_address,
 				"tx_data":     tx_data,
-				"amount_hb":   strconv.Itoa(amount_hb), // amount converted to string
+				"amount_hb":   strconv.FormatUint(amount_hb, 10), // amount in Hanas converted to string
+				"rec_label":   rec_label,
 			}
 			return "", true, inputs
 		}
-
+		// CheckInputErrors printed the error
+		return "", false, nil
 	} else {
 		tbfunctions.PrintTxnHelp()
 		return "", false, nil
 	}
-	return "", true, nil
 }
 
-func CheckInputErrors(rec_address string, amount_hb int) bool {
+// resolveRecipient turns a contact, Bech32m or 0x hex recipient into lower case
+// hex on network, without the checksum checks of a transfer
+func resolveRecipient(input string, network string) (string, error) {
+	if strings.HasPrefix(input, "@") {
+		contact, err := tbfunctions.ResolveNetworkContact(input, network)
+		return contact.Address, err
+	}
+	if tbfunctions.IsBech32Address(input) {
+		return tbfunctions.ParseNetworkBech32Address(input, network)
+	}
+	if len(input) != 42 || !VerifyAddressFormat(input) {
+		return "", fmt.Errorf("invalid address %q", input)
+	}
+	return strings.ToLower(input), nil
+}
+
+// CheckAddressChecksum rejects recipient addresses with a wrong EIP-55 checksum.
+// Addresses without a checksum need --allow-lowercase and get a warning.
+func CheckAddressChecksum(rec_address string) (string, bool) {
+	if !VerifyAddressFormat(rec_address) {
+		// Format errors are reported by CheckInputErrors
+		return "", true
+	}
+	err := keys.VerifyAddressChecksum(rec_address)
+	if err == keys.ErrAddressNoChecksum {
+		if !tbfunctions.HasFlag("--allow-lowercase") {
+			returnError := `
++--------------------------------------------------------------+
+| Error:  Recipient address has no EIP-55 checksum             |
+| Reason: A mistyped character can't be detected, paste the    |
+|         mixed-case address or add --allow-lowercase          |
++--------------------------------------------------------------+
+					`
+			return returnError, false
+		}
+		fmt.Println(`
++--------------------------------------------------------------+
+| Warning: Recipient address has no checksum, double check it  |
++--------------------------------------------------------------+`)
+	} else if err != nil {
+		returnError := `
++--------------------------------------------------------------+
+| Error:  Recipient address checksum is invalid                |
+| Reason: The address has a mistyped character                 |
++--------------------------------------------------------------+
+					`
+		return returnError, false
+	}
+	return "", true
+}
+
+// parseTxnAmount reads the amount of a txn in Hanas. A plain number is in the
+// configured amount unit, --unit only changes how amounts are printed.
+func parseTxnAmount(input string) (uint64, error) {
+	hanas, err := amounts.Parse(input, tbfunctions.InputUnit())
+	if err != nil {
+		return 0, err
+	}
+	return amounts.Uint64(hanas)
+}
+
+func CheckInputErrors(rec_address string, amount_hb uint64) bool {
 
 	// Check recipient address length
 	if len(rec_address) != 42 {
@@ -75,8 +173,18 @@ func CheckInputErrors(rec_address string, amount_hb int) bool {
 		return false // Return false immediately
 	}
 
+	// Check the 0x prefix and the hex characters
+	if !VerifyAddressFormat(rec_address) {
+		fmt.Println(`
++--------------------------------------------------------+
+| Error: Recipient address must be 0x and 40 hex chars   |
++--------------------------------------------------------+
+			`)
+		return false // Return false immediately
+	}
+
 	// Check if the amount is greater than zero
-	if amount_hb <= 0 {
+	if amount_hb == 0 {
 		fmt.Println(`
 +------------------------------------------+
 | Error: Amount cannot be 0 or negative    |
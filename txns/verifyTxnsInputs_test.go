@@ -0,0 +1,67 @@
+// verifyTxnsInputs_test.go
+package txns
+
+import "testing"
+
+// Recipients that aren't 0x and 40 hex characters, none of them may panic
+var malformedRecipients = []string{
+	"",
+	"x",
+	"0",
+	"0x",
+	"0x6Fac4D18",
+	"6Fac4D18c912343BF86fa7049364Dd4E424Ab9C0",
+	"zz6Fac4D18c912343BF86fa7049364Dd4E424Ab9C0",
+	"0x6Fac4D18c912343BF86fa7049364Dd4E424Ab9CZ",
+	"0x6Fac4D18c912343BF86fa7049364Dd4E424Ab9C00",
+}
+
+func TestVerifyAddressFormat(t *testing.T) {
+	for _, address := range malformedRecipients {
+		if VerifyAddressFormat(address) {
+			t.Errorf("VerifyAddressFormat(%q) = true", address)
+		}
+	}
+	for _, address := range []string{"0x6Fac4D18c912343BF86fa7049364Dd4E424Ab9C0", "0x6fac4d18c912343bf86fa7049364dd4e424ab9c0"} {
+		if !VerifyAddressFormat(address) {
+			t.Errorf("VerifyAddressFormat(%q) = false", address)
+		}
+	}
+}
+
+func TestMalformedRecipients(t *testing.T) {
+	for _, address := range malformedRecipients {
+		// Format errors are left to CheckInputErrors
+		if returnError, isValid := CheckAddressChecksum(address); !isValid {
+			t.Errorf("CheckAddressChecksum(%q) = %q, want the format left to CheckInputErrors", address, returnError)
+		}
+		if CheckInputErrors(address, 5) {
+			t.Errorf("CheckInputErrors(%q) = true", address)
+		}
+		if _, err := resolveRecipient(address, "mainnet"); err == nil {
+			t.Errorf("resolveRecipient(%q) returned no error", address)
+		}
+	}
+}
+
+func TestCheckAddressChecksum(t *testing.T) {
+	if _, isValid := CheckAddressChecksum("0x6Fac4D18c912343BF86fa7049364Dd4E424Ab9C0"); !isValid {
+		t.Error("a checksummed address was rejected")
+	}
+	if _, isValid := CheckAddressChecksum("0x6FAc4D18c912343BF86fa7049364Dd4E424Ab9C0"); isValid {
+		t.Error("an address with a wrong checksum was accepted")
+	}
+	// Lower case needs --allow-lowercase
+	if _, isValid := CheckAddressChecksum("0x6fac4d18c912343bf86fa7049364dd4e424ab9c0"); isValid {
+		t.Error("an address without a checksum was accepted")
+	}
+}
+
+func TestCheckInputErrors(t *testing.T) {
+	if !CheckInputErrors("0x6fac4d18c912343bf86fa7049364dd4e424ab9c0", 5) {
+		t.Error("a valid recipient and amount were rejected")
+	}
+	if CheckInputErrors("0x6fac4d18c912343bf86fa7049364dd4e424ab9c0", 0) {
+		t.Error("an amount of 0 was accepted")
+	}
+}